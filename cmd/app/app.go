@@ -1,14 +1,22 @@
 package app
 
 import (
+	"fmt"
 	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/oluwatobi1/gh-api-data-fetch/config"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/api"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/application/handlers"
-	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
 	"go.uber.org/zap"
 	"gorm.io/driver/sqlite"
 	gm "gorm.io/gorm"
@@ -32,7 +40,9 @@ func (s *APPServer) Run() {
 	if err != nil {
 		log.Fatal("failed to connect database:", err)
 	}
-	db.AutoMigrate(&models.Repository{}, &models.Commit{})
+	if err := gorm.Migrate(db); err != nil {
+		log.Fatal("failed to migrate database:", err)
+	}
 
 	logger := zap.Must(zap.NewDevelopment())
 	if config.Env.ENVIRONMENT == "release" {
@@ -53,8 +63,31 @@ func initializeApp(db *gm.DB, logger *zap.Logger) {
 	logger.Info("initializeApp")
 	repoRepo := gorm.NewRepository(db)
 	commitRepo := gorm.NewCommitRepo(db)
-	ghApi := api.NewGitHubAPI(config.Env.GITHUB_TOKEN, logger)
-	appHandler := handlers.NewAppHandler(repoRepo, commitRepo, ghApi, logger)
+	identityRepo := gorm.NewIdentityRepo(db)
+	pullRequestRepo := gorm.NewPullRequestRepo(db)
+	issueRepo := gorm.NewIssueRepo(db)
+	releaseRepo := gorm.NewReleaseRepo(db)
+	statsRepo := gorm.NewStatsRepo(db)
+	workflowRepo := gorm.NewWorkflowRepo(db)
+	enrollmentRepo := gorm.NewEnrollmentRepo(db)
+	httpCache := gorm.NewHTTPCacheRepo(db)
+	opts := githubOptions(httpCache, logger)
+	if config.Env.GITHUB_AUTH_MODE == "app" {
+		app, err := githubApp(repoRepo)
+		if err != nil {
+			logger.Sugar().Fatal("GitHub App authentication: ", err)
+		}
+		opts.App = app
+	}
+	var ghApi ports.GithubService
+	if config.Env.GITHUB_COMMIT_FETCHER == "graphql" {
+		ghApi = api.NewGraphQLAPI(opts, logger)
+	} else {
+		ghApi = api.NewGitHubAPI(opts, logger)
+	}
+	appHandler := handlers.NewAppHandler(repoRepo, commitRepo, identityRepo, pullRequestRepo, issueRepo, releaseRepo, statsRepo, workflowRepo, enrollmentRepo, ghApi, logger)
+	appHandler.Backfill = backfillOptions(logger)
+	appHandler.EnrichNewRepos, _ = strconv.ParseBool(config.Env.ENRICH_COMMITS)
 	appHandler.SetupEventBus()
 	setupApp(appHandler, logger)
 	configureRoutes(appHandler)
@@ -71,5 +104,128 @@ func setupApp(app *handlers.AppHandler, logger *zap.Logger) {
 	} else {
 		logger.Sugar().Warn("DEFAULT_REPO Not Specified")
 	}
+	if config.Env.ENROLL_OWNERS != "" {
+		requests, err := utils.ParseEnrollmentSpecs(config.Env.ENROLL_OWNERS)
+		if err != nil {
+			logger.Sugar().Warn("Invalid ENROLL_OWNERS, ignoring: ", err)
+			return
+		}
+		for _, req := range requests {
+			if _, err := app.Enroll(req); err != nil {
+				logger.Sugar().Warn("Error enrolling ", req.Owner, ": ", err)
+			}
+		}
+	}
+
+}
+
+func githubOptions(cache ports.HTTPCache, logger *zap.Logger) api.Options {
+	// a zero timeout leaves the adapter's default in place
+	httpClient := &http.Client{}
+	if config.Env.GITHUB_HTTP_TIMEOUT != "" {
+		if timeout, err := time.ParseDuration(config.Env.GITHUB_HTTP_TIMEOUT); err == nil {
+			httpClient.Timeout = timeout
+		} else {
+			logger.Sugar().Warn("Invalid GITHUB_HTTP_TIMEOUT, using default: ", err)
+		}
+	}
+	if config.Env.GITHUB_PROXY_URL != "" {
+		if proxyURL, err := url.Parse(config.Env.GITHUB_PROXY_URL); err == nil {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.Proxy = http.ProxyURL(proxyURL)
+			httpClient.Transport = transport
+		} else {
+			logger.Sugar().Warn("Invalid GITHUB_PROXY_URL, ignoring: ", err)
+		}
+	}
+	return api.Options{
+		BaseURL:    config.Env.GITHUB_API_URL,
+		HTTPClient: httpClient,
+		UserAgent:  config.Env.GITHUB_USER_AGENT,
+		Token:      config.Env.GITHUB_TOKEN,
+		Tokens:     strings.Split(config.Env.GITHUB_TOKENS, ","),
+		Cache:      cache,
+		Retry:      retryPolicy(logger),
+	}
+}
+
+func retryPolicy(logger *zap.Logger) api.RetryPolicy {
+	policy := api.DefaultRetryPolicy
+	if v := config.Env.GITHUB_RETRY_MAX_ATTEMPTS; v != "" {
+		if attempts, err := strconv.Atoi(v); err == nil {
+			policy.MaxAttempts = attempts
+		} else {
+			logger.Sugar().Warn("Invalid GITHUB_RETRY_MAX_ATTEMPTS, using default: ", err)
+		}
+	}
+	if v := config.Env.GITHUB_RETRY_BASE_DELAY; v != "" {
+		if delay, err := time.ParseDuration(v); err == nil {
+			policy.BaseDelay = delay
+		} else {
+			logger.Sugar().Warn("Invalid GITHUB_RETRY_BASE_DELAY, using default: ", err)
+		}
+	}
+	if v := config.Env.GITHUB_RETRY_MAX_DELAY; v != "" {
+		if delay, err := time.ParseDuration(v); err == nil {
+			policy.MaxDelay = delay
+		} else {
+			logger.Sugar().Warn("Invalid GITHUB_RETRY_MAX_DELAY, using default: ", err)
+		}
+	}
+	if v := config.Env.GITHUB_RETRY_JITTER; v != "" {
+		if jitter, err := strconv.ParseFloat(v, 64); err == nil {
+			policy.Jitter = jitter
+		} else {
+			logger.Sugar().Warn("Invalid GITHUB_RETRY_JITTER, using default: ", err)
+		}
+	}
+	return policy
+}
+
+func backfillOptions(logger *zap.Logger) handlers.BackfillOptions {
+	var opts handlers.BackfillOptions
+	if v := config.Env.BACKFILL_WORKERS; v != "" {
+		if workers, err := strconv.Atoi(v); err == nil {
+			opts.Workers = workers
+		} else {
+			logger.Sugar().Warn("Invalid BACKFILL_WORKERS, using default: ", err)
+		}
+	}
+	if v := config.Env.BACKFILL_WINDOW; v != "" {
+		if window, err := time.ParseDuration(v); err == nil {
+			opts.Window = window
+		} else {
+			logger.Sugar().Warn("Invalid BACKFILL_WINDOW, using default: ", err)
+		}
+	}
+	return opts
+}
 
+// githubApp loads the App credentials, seeding installations from config and from
+// repositories already mapped to one.
+func githubApp(repoRepo ports.Repository) (*api.AppAuth, error) {
+	privateKey, err := os.ReadFile(config.Env.GITHUB_APP_PRIVATE_KEY_PATH)
+	if err != nil {
+		return nil, err
+	}
+	installations := make(map[string]int64)
+	if repos, err := repoRepo.FindAll(); err == nil {
+		for _, repo := range repos {
+			if repo.InstallationID != 0 {
+				installations[strings.SplitN(repo.FullName, "/", 2)[0]] = repo.InstallationID
+			}
+		}
+	}
+	for _, pair := range strings.Split(config.Env.GITHUB_APP_INSTALLATIONS, ",") {
+		owner, id, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			continue
+		}
+		installationID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid installation id for %s: %w", owner, err)
+		}
+		installations[owner] = installationID
+	}
+	return api.NewAppAuth(config.Env.GITHUB_APP_ID, privateKey, installations)
 }
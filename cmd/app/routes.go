@@ -7,9 +7,42 @@ import (
 func configureRoutes(appHandler *handlers.AppHandler) {
 	v1 := router.Group("/api/v1")
 	v1.GET("/fetch-repo", appHandler.FetchRepository)
+	v1.GET("/repository", appHandler.GetRepository)
 	v1.GET("/top-commit-authors", appHandler.GetTopCommitAuthors)
 	v1.GET("/commits", appHandler.FetchCommitsByRepoName)
-	// v1.GET("/list-repo", appHandler.ListRepositories)
+	v1.GET("/commits/:sha/ci", appHandler.GetCommitCIStatus)
+	v1.GET("/pull-requests", appHandler.FetchPullRequestsByRepoName)
+	v1.GET("/pull-requests/:number", appHandler.GetPullRequest)
+	v1.GET("/issues", appHandler.FetchIssuesByRepoName)
+	v1.GET("/issues/:number", appHandler.GetIssue)
+	v1.GET("/releases", appHandler.FetchReleasesByRepoName)
+	v1.GET("/releases/report", appHandler.GetReleaseReport)
+	v1.GET("/repos", appHandler.ListRepositories)
+	v1.GET("/repos/:owner/:repo", appHandler.GetRepositoryByName)
+	v1.DELETE("/repos/:owner/:repo", appHandler.DeleteRepository)
+	v1.POST("/repos/:owner/:repo/pause", appHandler.PauseRepository)
+	v1.POST("/repos/:owner/:repo/resume", appHandler.ResumeRepository)
+	v1.POST("/repos/:owner/:repo/resync", appHandler.ResyncRepository)
+	v1.POST("/repos/:owner/:repo/reset-cursor", appHandler.ResetRepositoryCursor)
+	v1.GET("/repos/:owner/:repo/metrics/reviews", appHandler.GetReviewMetrics)
+	v1.GET("/repos/:owner/:repo/metrics/workflows", appHandler.GetWorkflowMetrics)
+	v1.GET("/repos/:owner/:repo/history", appHandler.GetRepositoryHistory)
+	v1.GET("/rate-limit", appHandler.GetRateLimit)
+	v1.GET("/tokens", appHandler.GetTokenStatus)
+	v1.GET("/retries", appHandler.GetRetryStats)
+	v1.GET("/backfill", appHandler.GetBackfillProgress)
+	v1.POST("/enrichment", appHandler.SetCommitEnrichment)
+	v1.GET("/branches", appHandler.GetBranches)
+	v1.PUT("/branches", appHandler.SetBranchPatterns)
+	v1.GET("/history-rewrites", appHandler.ListHistoryRewrites)
+	v1.GET("/enrollments", appHandler.ListEnrollments)
+	v1.POST("/enrollments", appHandler.CreateEnrollment)
+	v1.DELETE("/enrollments/:owner", appHandler.DeleteEnrollment)
+	v1.GET("/identities", appHandler.ListIdentities)
+	v1.GET("/identities/aliases", appHandler.ListAliasRules)
+	v1.POST("/identities/aliases", appHandler.CreateAliasRule)
+	v1.DELETE("/identities/aliases/:id", appHandler.DeleteAliasRule)
+	v1.POST("/identities/mailmap", appHandler.ImportMailmap)
 	// v1.GET("/list-commit", appHandler.ListCommits)
 
 }
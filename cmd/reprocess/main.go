@@ -0,0 +1,34 @@
+// Command reprocess rebuilds data derived from stored commits, the participants
+// parsed from message trailers and the author identities, for rows stored by
+// older versions.
+package main
+
+import (
+	"log"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/config"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
+	"gorm.io/driver/sqlite"
+	gm "gorm.io/gorm"
+)
+
+const batchSize = 500
+
+func main() {
+	if err := config.LoadConfig(); err != nil {
+		log.Fatalln(err)
+	}
+	db, err := gm.Open(sqlite.Open(config.Env.DB_URL), &gm.Config{})
+	if err != nil {
+		log.Fatal("failed to connect database:", err)
+	}
+	if err := gorm.Migrate(db); err != nil {
+		log.Fatal("failed to migrate database:", err)
+	}
+
+	processed, err := gorm.NewCommitRepo(db).Reprocess(batchSize)
+	if err != nil {
+		log.Fatalf("reprocessing failed after %d commits: %v", processed, err)
+	}
+	log.Printf("Reprocessed %d commits", processed)
+}
@@ -7,14 +7,50 @@ import (
 	"github.com/spf13/viper"
 )
 
+// Config holds the settings read from the environment. Fields tagged secret are
+// masked when the settings are logged at startup.
 type Config struct {
-	DB_URL       string `mapstructure:"DB_URL"`
+	DB_URL       string `mapstructure:"DB_URL" secret:"true"`
 	PORT         string `mapstructure:"PORT"`
 	ENVIRONMENT  string `mapstructure:"ENVIRONMENT"`
-	GITHUB_TOKEN string `mapstructure:"GITHUB_TOKEN"`
+	GITHUB_TOKEN string `mapstructure:"GITHUB_TOKEN" secret:"true"`
 	START_DATE   string `mapstructure:"START_DATE"`
 	END_DATE     string `mapstructure:"END_DATE"`
 	DEFAULT_REPO string `mapstructure:"DEFAULT_REPO"`
+
+	// comma separated list of extra tokens rotated with GITHUB_TOKEN
+	GITHUB_TOKENS       string `mapstructure:"GITHUB_TOKENS" secret:"true"`
+	GITHUB_API_URL      string `mapstructure:"GITHUB_API_URL"`
+	GITHUB_USER_AGENT   string `mapstructure:"GITHUB_USER_AGENT"`
+	GITHUB_HTTP_TIMEOUT string `mapstructure:"GITHUB_HTTP_TIMEOUT"`
+	GITHUB_PROXY_URL    string `mapstructure:"GITHUB_PROXY_URL" secret:"true"`
+
+	// "rest" (default) or "graphql"
+	GITHUB_COMMIT_FETCHER string `mapstructure:"GITHUB_COMMIT_FETCHER"`
+
+	GITHUB_RETRY_MAX_ATTEMPTS string `mapstructure:"GITHUB_RETRY_MAX_ATTEMPTS"`
+	GITHUB_RETRY_BASE_DELAY   string `mapstructure:"GITHUB_RETRY_BASE_DELAY"`
+	GITHUB_RETRY_MAX_DELAY    string `mapstructure:"GITHUB_RETRY_MAX_DELAY"`
+	GITHUB_RETRY_JITTER       string `mapstructure:"GITHUB_RETRY_JITTER"`
+
+	// "token" (default) or "app"
+	GITHUB_AUTH_MODE            string `mapstructure:"GITHUB_AUTH_MODE"`
+	GITHUB_APP_ID               string `mapstructure:"GITHUB_APP_ID"`
+	GITHUB_APP_PRIVATE_KEY_PATH string `mapstructure:"GITHUB_APP_PRIVATE_KEY_PATH"`
+	// comma separated owner=installation_id pairs, e.g. my-org=123,other-org=456
+	GITHUB_APP_INSTALLATIONS string `mapstructure:"GITHUB_APP_INSTALLATIONS"`
+
+	// parallel import of new repositories; 1 keeps it sequential
+	BACKFILL_WORKERS string `mapstructure:"BACKFILL_WORKERS"`
+	// length of each date window, e.g. 720h
+	BACKFILL_WINDOW string `mapstructure:"BACKFILL_WINDOW"`
+
+	// "true" enables file and line stat enrichment for newly added repositories
+	ENRICH_COMMITS string `mapstructure:"ENRICH_COMMITS"`
+
+	// semicolon separated owners whose repositories are all monitored, each with
+	// optional filters as a query string, e.g. my-org?language=Go;other-user
+	ENROLL_OWNERS string `mapstructure:"ENROLL_OWNERS"`
 }
 
 var Env *Config = &Config{}
@@ -38,8 +74,9 @@ func LoadConfig() error {
 	rc := reflect.ValueOf(Env).Elem()
 	count := 0
 	for i := 0; i < rc.NumField(); i++ {
-		pName := reflect.TypeOf(Config{}).Field(i).Name
-		log.Println(pName, viper.GetString(pName))
+		field := reflect.TypeOf(Config{}).Field(i)
+		pName := field.Name
+		log.Println(pName, logValue(field, viper.GetString(pName)))
 		rc.FieldByName(pName).SetString(viper.GetString(pName))
 		count += len(viper.GetString(pName))
 	}
@@ -49,3 +86,11 @@ func LoadConfig() error {
 	}
 	return nil
 }
+
+// logValue hides the value of secret fields, only telling whether they are set
+func logValue(field reflect.StructField, value string) string {
+	if field.Tag.Get("secret") != "true" || value == "" {
+		return value
+	}
+	return "********"
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// GitHub rejects App JWTs valid for more than 10 minutes
+	appJWTLifetime = 9 * time.Minute
+	// installation tokens are refreshed this long before they expire
+	installationTokenRefresh = 5 * time.Minute
+)
+
+// AppAuth authenticates as a GitHub App. A JWT signed with the App's private key
+// is exchanged for installation access tokens, one per account the App is installed on.
+type AppAuth struct {
+	appID      string
+	key        *rsa.PrivateKey
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+
+	mu            sync.Mutex
+	installations map[string]int64
+	tokens        map[int64]*installationToken
+}
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewAppAuth takes the App ID, the PEM encoded private key downloaded from the App
+// settings page and any known owner to installation ID mappings; other owners are looked up.
+func NewAppAuth(appID string, privateKey []byte, installations map[string]int64) (*AppAuth, error) {
+	if appID == "" {
+		return nil, errors.New("missing GitHub App ID")
+	}
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	app := &AppAuth{
+		appID:         appID,
+		key:           key,
+		installations: make(map[string]int64),
+		tokens:        make(map[int64]*installationToken),
+	}
+	for owner, id := range installations {
+		app.installations[strings.ToLower(owner)] = id
+	}
+	return app, nil
+}
+
+// bind makes the App talk to the same API and through the same HTTP client as c
+func (a *AppAuth) bind(c *client) {
+	a.baseURL = c.baseURL
+	a.httpClient = c.httpClient
+	a.userAgent = c.userAgent
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// JWT returns a token authenticating as the App itself
+func (a *AppAuth) JWT() (string, error) {
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		// backdated to allow for clock drift
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": a.appID,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// InstallationID returns the installation covering repoName ("owner/repo"), or
+// the account itself when only an owner is given, looking it up once per owner
+func (a *AppAuth) InstallationID(repoName string) (int64, error) {
+	owner := strings.ToLower(strings.SplitN(repoName, "/", 2)[0])
+	a.mu.Lock()
+	id, ok := a.installations[owner]
+	a.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/installation", a.baseURL, repoName)
+	if !strings.Contains(repoName, "/") {
+		// answers for organizations as well as users
+		url = fmt.Sprintf("%s/users/%s/installation", a.baseURL, repoName)
+	}
+	var installation struct {
+		ID int64 `json:"id"`
+	}
+	if err := a.appRequest("GET", url, &installation); err != nil {
+		return 0, fmt.Errorf("no GitHub App installation for %s: %w", repoName, err)
+	}
+	a.mu.Lock()
+	a.installations[owner] = installation.ID
+	a.mu.Unlock()
+	return installation.ID, nil
+}
+
+// installationToken returns a cached access token, exchanging the JWT for a new one when it is about to expire
+func (a *AppAuth) installationToken(id int64) (string, error) {
+	a.mu.Lock()
+	cached, ok := a.tokens[id]
+	a.mu.Unlock()
+	if ok && time.Until(cached.ExpiresAt) > installationTokenRefresh {
+		return cached.Token, nil
+	}
+
+	var token installationToken
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", a.baseURL, id)
+	if err := a.appRequest("POST", url, &token); err != nil {
+		return "", fmt.Errorf("failed to create installation token: %w", err)
+	}
+	a.mu.Lock()
+	a.tokens[id] = &token
+	a.mu.Unlock()
+	return token.Token, nil
+}
+
+// invalidate drops a cached installation token so the next request fetches a fresh one
+func (a *AppAuth) invalidate(id int64) {
+	a.mu.Lock()
+	delete(a.tokens, id)
+	a.mu.Unlock()
+}
+
+func (a *AppAuth) appRequest(method, url string, out interface{}) error {
+	jwt, err := a.JWT()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", a.userAgent)
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return decodeError(err)
+	}
+	return nil
+}
+
+// installationCredential authenticates requests with an installation access token
+type installationCredential struct {
+	app   *AppAuth
+	id    int64
+	owner string
+}
+
+func (c installationCredential) ID() string {
+	return fmt.Sprintf("installation:%d (%s)", c.id, c.owner)
+}
+
+func (c installationCredential) Token() (string, error) {
+	return c.app.installationToken(c.id)
+}
+
+func (c installationCredential) invalidate() {
+	c.app.invalidate(c.id)
+}
+
+type repoContextKey struct{}
+
+// withRepo tags a request context with the "owner/repo" it is made for, for
+// requests such as GraphQL whose URL does not name the repository
+func withRepo(ctx context.Context, repoName string) context.Context {
+	return context.WithValue(ctx, repoContextKey{}, repoName)
+}
+
+// repoFromRequest finds the "owner/repo" a request targets, or only the owner
+// for account requests such as listing the repositories of a user or organization
+func repoFromRequest(req *http.Request) string {
+	if repoName, ok := req.Context().Value(repoContextKey{}).(string); ok {
+		return repoName
+	}
+	parts := strings.Split(req.URL.Path, "/")
+	for i, part := range parts {
+		if part == "repos" && i+2 < len(parts) {
+			return parts[i+1] + "/" + parts[i+2]
+		}
+		if (part == "users" || part == "orgs") && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
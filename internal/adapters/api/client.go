@@ -0,0 +1,253 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultBaseURL   = "https://api.github.com"
+	DefaultUserAgent = "gh-api-data-fetch"
+	defaultTimeout   = 30 * time.Second
+	// how many times a request is re-sent after waiting out a rate limit
+	maxRateLimitWaits = 3
+)
+
+// Options configures how the GitHub adapter talks to the API.
+// Zero values fall back to public github.com defaults.
+type Options struct {
+	// BaseURL is the REST API root, e.g. https://github.example.com/api/v3 for GHES.
+	BaseURL string
+	// HTTPClient is used for every outbound call (timeouts, transport, proxy). A
+	// zero Timeout is replaced by the default one.
+	HTTPClient *http.Client
+	UserAgent  string
+	// Token and Tokens are rotated through, preferring the one with the most quota left.
+	Token  string
+	Tokens []string
+	// Cache stores ETag/Last-Modified validators; nil disables conditional requests.
+	Cache ports.HTTPCache
+	// App switches to GitHub App authentication for repository requests.
+	App *AppAuth
+	// SlowdownThreshold is the fraction of quota below which requests are paced; defaults to 0.1.
+	SlowdownThreshold float64
+	Retry             RetryPolicy
+}
+
+// client sends requests to GitHub with the configured credentials and headers.
+type client struct {
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+	tokens     *TokenPool
+	cache      ports.HTTPCache
+	retry      RetryPolicy
+	retries    retryCounter
+	logger     *zap.Logger
+}
+
+func newClient(opts Options, logger *zap.Logger) *client {
+	c := &client{
+		baseURL:    strings.TrimRight(opts.BaseURL, "/"),
+		httpClient: opts.HTTPClient,
+		userAgent:  opts.UserAgent,
+		cache:      opts.Cache,
+		retry:      opts.Retry.withDefaults(),
+		logger:     logger,
+	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultBaseURL
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: defaultTimeout}
+	} else if c.httpClient.Timeout == 0 {
+		httpClient := *c.httpClient
+		httpClient.Timeout = defaultTimeout
+		c.httpClient = &httpClient
+	}
+	if c.userAgent == "" {
+		c.userAgent = DefaultUserAgent
+	}
+	var creds []credential
+	for _, token := range append([]string{opts.Token}, opts.Tokens...) {
+		if token = strings.TrimSpace(token); token != "" {
+			creds = append(creds, staticToken(token))
+		}
+	}
+	if opts.App != nil {
+		opts.App.bind(c)
+	}
+	c.tokens = newTokenPool(creds, opts.App, opts.SlowdownThreshold, logger)
+	return c
+}
+
+// Do implements utils.HTTPDoer, adding auth and identification headers.
+// Transient failures are retried with exponential backoff and jitter. Responses
+// other than 2xx and 304 are returned as the typed errors in errors.go.
+func (c *client) Do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(req)
+		if err == nil {
+			err = bufferBody(resp)
+		}
+		retry, reason := retryable(resp, err)
+		if !retry {
+			return finish(resp, err)
+		}
+		if attempt >= c.retry.MaxAttempts {
+			c.retries.exhausted()
+			c.logger.Sugar().Errorf("Giving up on %s after %d attempts: %s", req.URL, attempt, reason)
+			return finish(resp, err)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		delay := c.retry.backoff(attempt)
+		c.retries.retried(req.URL.String(), reason)
+		c.logger.Sugar().Warnf("Retrying %s (attempt %d/%d) in %s: %s", req.URL, attempt+1, c.retry.MaxAttempts, delay.Round(time.Millisecond), reason)
+		time.Sleep(delay)
+		if err := rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// finish converts failed responses into the adapter's typed errors
+func finish(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// send makes one logical attempt. It uses the installation token of the target
+// repository in App mode, or else the token with the most quota left; a
+// rate-limited request is re-sent with another token, or with the same one once
+// its limit resets.
+func (c *client) send(req *http.Request) (*http.Response, error) {
+	resource := resourceFor(req)
+	for attempt := 1; ; attempt++ {
+		token, err := c.tokens.pick(resource, repoFromRequest(req))
+		if err != nil {
+			return nil, err
+		}
+		if used, ok := req.Context().Value(usedTokenContextKey{}).(*usedToken); ok {
+			used.token = token
+		}
+		token.limiter.Wait(resource)
+		if err := token.authorize(req); err != nil {
+			c.tokens.fail(token, err.Error(), false)
+			return nil, err
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		token.limiter.Observe(resp)
+
+		retry := false
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized:
+			c.tokens.fail(token, "401 bad credentials", true)
+			retry = c.tokens.usable() > 0
+		case token.limiter.Limited(resp):
+			c.tokens.fail(token, fmt.Sprintf("rate limited (%d)", resp.StatusCode), false)
+			retry = true
+		}
+		if !retry || attempt > maxRateLimitWaits+len(c.tokens.tokens) {
+			return resp, nil
+		}
+		resp.Body.Close()
+		c.logger.Sugar().Warnf("Request to %s rejected for token %s (attempt %d), rotating", req.URL, token.cred.ID(), attempt)
+		if err := rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// conditional returns a doer that sends If-None-Match/If-Modified-Since from the cache.
+// With keepBody the 200 body is cached too, so a later 304 can be answered from it.
+func (c *client) conditional(repoName string, keepBody bool) *conditionalDoer {
+	return &conditionalDoer{client: c, repoName: repoName, keepBody: keepBody}
+}
+
+// conditionalDoer holds validators from a 200 response until commit is called,
+// so a page that failed to be stored is requested again in full on the next run.
+type conditionalDoer struct {
+	client   *client
+	repoName string
+	keepBody bool
+	cached   *models.HTTPCacheEntry
+	pending  *models.HTTPCacheEntry
+}
+
+func (d *conditionalDoer) Do(req *http.Request) (*http.Response, error) {
+	cache := d.client.cache
+	if cache == nil {
+		return d.client.Do(req)
+	}
+	url := req.URL.String()
+	// an entry saved without its body can't answer a 304 for a caller that needs it
+	if entry, err := cache.Get(url); err == nil && (!d.keepBody || entry.Body != nil) {
+		d.cached = entry
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return resp, nil
+	}
+	entry := &models.HTTPCacheEntry{
+		URL:          url,
+		RepoName:     d.repoName,
+		ETag:         etag,
+		LastModified: lastModified,
+	}
+	if d.keepBody {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		entry.Body = body
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	d.pending = entry
+	return resp, nil
+}
+
+// commit persists the validators of the last 200 response
+func (d *conditionalDoer) commit() error {
+	if d.pending == nil || d.client.cache == nil {
+		return nil
+	}
+	err := d.client.cache.Save(d.pending)
+	d.pending = nil
+	return err
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+)
+
+// Sentinels for errors.Is, defined in ports so the application does not depend
+// on the adapter. Every error returned for a failed GitHub response matches one of them.
+var (
+	ErrNotFound     = ports.ErrNotFound
+	ErrUnauthorized = ports.ErrUnauthorized
+	ErrForbidden    = ports.ErrForbidden
+	ErrRateLimited  = ports.ErrRateLimited
+	ErrUpstream     = ports.ErrUpstream
+	ErrDecode       = ports.ErrDecode
+	ErrComputing    = ports.ErrComputing
+)
+
+// StatusError is a non-success response from GitHub
+type StatusError struct {
+	StatusCode int
+	Message    string
+	URL        string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("github: %s %s (status: %d)", e.URL, e.Message, e.StatusCode)
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrUpstream:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// RateLimitError is returned when a primary or secondary rate limit is still in
+// force after the client has waited for it
+type RateLimitError struct {
+	StatusError
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("github: rate limit exceeded until %s: %s", e.Reset.Format(time.RFC3339), e.Message)
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RetryAfter is how long callers should wait before trying again
+func (e *RateLimitError) RetryAfter() time.Duration {
+	if wait := time.Until(e.Reset); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// DecodeError is a response body that could not be parsed
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "github: failed to decode response: " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
+
+// checkResponse turns a failed response into a typed error, closing its body.
+// 2xx and 304 responses are returned untouched.
+func checkResponse(resp *http.Response) error {
+	if (resp.StatusCode >= 200 && resp.StatusCode < 300) || resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	statusErr := StatusError{StatusCode: resp.StatusCode, URL: resp.Request.URL.String()}
+	var apiError types.ApiError
+	body, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &apiError); err == nil && apiError.Message != "" {
+		statusErr.Message = apiError.Message
+	} else {
+		statusErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	reset, limited := rateLimitReset(resp)
+	if !limited && resp.StatusCode == http.StatusForbidden {
+		// secondary limits are sometimes only recognisable from the message
+		limited = strings.Contains(strings.ToLower(statusErr.Message), "rate limit")
+	}
+	if limited {
+		return &RateLimitError{StatusError: statusErr, Reset: reset}
+	}
+	return &statusErr
+}
+
+// rateLimitReset reports whether a 403/429 response is a rate limit and when it lifts
+func rateLimitReset(resp *http.Response) (time.Time, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second), true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(reset, 0), true
+		}
+		return time.Now().Add(secondaryLimitWait), true
+	}
+	return time.Now().Add(secondaryLimitWait), resp.StatusCode == http.StatusTooManyRequests
+}
+
+// decodeError wraps JSON parsing failures in DecodeError, leaving other errors as they are
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return &DecodeError{Err: err}
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+)
+
+// workflowRunsCap is the most runs GitHub returns for a run listing filtered by creation time
+const workflowRunsCap = 1000
+
+// FetchWorkflowRuns pages through the workflow runs of a repository created
+// since since, all of them when nil, newest first, handing each page to handle
+func (gh *GitHubAPI) FetchWorkflowRuns(repoName string, repoID uint, since *time.Time, handle ports.WorkflowRunPageHandler) error {
+	runsURL := fmt.Sprintf("%s/repos/%s/actions/runs?per_page=100", gh.client.baseURL, repoName)
+	if since != nil {
+		runsURL += "&created=" + url.QueryEscape(">="+since.UTC().Format(time.RFC3339))
+	}
+	total, matching := 0, 0
+	err := gh.fetchPages(runsURL, func(body io.Reader) error {
+		var page struct {
+			TotalCount   int                          `json:"total_count"`
+			WorkflowRuns []models.WorkflowRunResponse `json:"workflow_runs"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return err
+		}
+		matching = page.TotalCount
+		if len(page.WorkflowRuns) == 0 {
+			return nil
+		}
+		runs := make([]models.WorkflowRun, 0, len(page.WorkflowRuns))
+		for _, r := range page.WorkflowRuns {
+			runs = append(runs, r.ToWorkflowRun(repoID))
+		}
+		total += len(runs)
+		return handle(runs)
+	})
+	if err != nil {
+		return err
+	}
+	gh.logger.Sugar().Info("Total Workflow Runs Fetched: ", total)
+	if since != nil && total >= workflowRunsCap && matching > total {
+		gh.logger.Sugar().Warnf("GitHub returned %d of the %d workflow runs of %s created since %s, the older ones are not synced",
+			total, matching, repoName, since.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// FetchWorkflowJobs returns the jobs of every attempt of a workflow run
+func (gh *GitHubAPI) FetchWorkflowJobs(repoName string, repoID uint, runID int64) ([]models.WorkflowJob, error) {
+	jobsURL := fmt.Sprintf("%s/repos/%s/actions/runs/%d/jobs?filter=all&per_page=100", gh.client.baseURL, repoName, runID)
+	var jobs []models.WorkflowJob
+	err := gh.fetchPages(jobsURL, func(body io.Reader) error {
+		var page struct {
+			Jobs []models.WorkflowJobResponse `json:"jobs"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return err
+		}
+		for _, j := range page.Jobs {
+			jobs = append(jobs, j.ToWorkflowJob(repoID))
+		}
+		return nil
+	})
+	return jobs, err
+}
@@ -1,9 +1,12 @@
 package api
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
@@ -13,86 +16,272 @@ import (
 )
 
 type GitHubAPI struct {
-	token  string
+	client *client
 	logger *zap.Logger
 }
 
-func NewGitHubAPI(token string, logger *zap.Logger) ports.GithubService {
-	return &GitHubAPI{token: token, logger: logger}
+func NewGitHubAPI(opts Options, logger *zap.Logger) ports.GithubService {
+	return &GitHubAPI{client: newClient(opts, logger), logger: logger}
+}
+
+func (gh *GitHubAPI) RateLimitStatus() []types.RateLimitStatus {
+	return gh.client.tokens.RateLimitStatus()
+}
+
+func (gh *GitHubAPI) TokenStatus() []types.TokenStatus {
+	return gh.client.tokens.Status()
+}
+
+func (gh *GitHubAPI) RetryStats() types.RetryStats {
+	return gh.client.retries.snapshot()
 }
 
 func (gh *GitHubAPI) FetchRepository(repoName string) (*models.Repository, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s", repoName)
-	req, _ := http.NewRequest("GET", url, nil)
-	if gh.token != "" {
-		req.Header.Set("Authorization", "Bearer "+gh.token)
+	url := fmt.Sprintf("%s/repos/%s", gh.client.baseURL, repoName)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	doer := gh.client.conditional(repoName, true)
+	resp, err := doer.Do(req)
 	if err != nil {
 		gh.logger.Sugar().Warn("FetchRepository Error, " + err.Error())
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		var apiError types.ApiError
-		if err := json.NewDecoder(resp.Body).Decode(&apiError); err != nil {
-			return nil, fmt.Errorf("failed to decode error response: %w", err)
-		}
-		switch resp.StatusCode {
-		case http.StatusNotFound:
-			return nil, fmt.Errorf("repository not found: %s", apiError.Message)
-		default:
-			return nil, fmt.Errorf("failed to fetch repository: %s (status: %d)", apiError.Message, resp.StatusCode)
+	if resp.StatusCode == http.StatusNotModified && doer.cached != nil {
+		gh.logger.Sugar().Info("Repository not modified since last fetch: ", repoName)
+		var repo models.Repository
+		if err := json.Unmarshal(doer.cached.Body, &repo); err != nil {
+			return nil, decodeError(err)
 		}
+		gh.setInstallation(&repo)
+		return &repo, nil
 	}
 
 	var repo models.Repository
 	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
 		gh.logger.Sugar().Warn("FetchRepository decode Error, " + err.Error())
-		return nil, err
+		return nil, decodeError(err)
+	}
+	if err := doer.commit(); err != nil {
+		gh.logger.Sugar().Warn("FetchRepository cache Error, " + err.Error())
 	}
+	gh.setInstallation(&repo)
 	return &repo, nil
 }
 
-func (gh *GitHubAPI) FetchCommits(repoName string, repoId uint, config models.CommitConfig) ([]models.Commit, string, int, error) {
-	var allCommits []models.CommitResponse
-	var errL error
-	var rateLimitDuration int
-	url := utils.BuildGHCommitURL(repoName, config)
+// FetchCommitDetail fetches a single commit with its stats and files. Commits
+// touching more than 300 files have their file list paginated; every page is followed.
+func (gh *GitHubAPI) FetchCommitDetail(repoName string, sha string) (*models.CommitResponse, error) {
+	url := fmt.Sprintf("%s/repos/%s/commits/%s", gh.client.baseURL, repoName, sha)
+	var detail *models.CommitResponse
+	for url != "" {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := gh.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var page models.CommitResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, decodeError(err)
+		}
+		if detail == nil {
+			detail = &page
+		} else {
+			detail.Files = append(detail.Files, page.Files...)
+		}
+		url = utils.ParseLinkHeader(resp.Header.Get("Link"))["next"]
+	}
+	return detail, nil
+}
+
+func (gh *GitHubAPI) FetchFile(repoName string, path string) ([]byte, error) {
+	url := fmt.Sprintf("%s/repos/%s/contents/%s", gh.client.baseURL, repoName, path)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := gh.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var file struct {
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return nil, decodeError(err)
+	}
+	if file.Encoding != "base64" {
+		return []byte(file.Content), nil
+	}
+	// GitHub wraps the base64 content at 60 characters
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	return content, nil
+}
+
+func (gh *GitHubAPI) ListBranches(repoName string) ([]string, error) {
+	url := fmt.Sprintf("%s/repos/%s/branches?per_page=100", gh.client.baseURL, repoName)
+	var names []string
+	for url != "" {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := gh.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var page []struct {
+			Name string `json:"name"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, decodeError(err)
+		}
+		for _, branch := range page {
+			names = append(names, branch.Name)
+		}
+		url = utils.ParseLinkHeader(resp.Header.Get("Link"))["next"]
+	}
+	return names, nil
+}
+
+func (gh *GitHubAPI) FetchBranch(repoName string, branch string) (*models.BranchResponse, error) {
+	url := fmt.Sprintf("%s/repos/%s/branches/%s", gh.client.baseURL, repoName, branch)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := gh.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var b models.BranchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&b); err != nil {
+		return nil, decodeError(err)
+	}
+	return &b, nil
+}
+
+// CompareCommits compares base with head, following every page of the
+// commits reachable from head but not from base
+func (gh *GitHubAPI) CompareCommits(repoName string, base string, head string) (*models.CompareResponse, error) {
+	url := fmt.Sprintf("%s/repos/%s/compare/%s...%s?per_page=100", gh.client.baseURL, repoName, base, head)
+	var comparison *models.CompareResponse
+	for url != "" {
+		page, next, err := gh.fetchComparison(url)
+		if err != nil {
+			return nil, err
+		}
+		if comparison == nil {
+			comparison = page
+		} else {
+			comparison.Commits = append(comparison.Commits, page.Commits...)
+		}
+		url = next
+	}
+	return comparison, nil
+}
+
+// CompareStatus compares base with head in a single request, for the status,
+// counts and merge base only; at most one of the commits is returned
+func (gh *GitHubAPI) CompareStatus(repoName string, base string, head string) (*models.CompareResponse, error) {
+	comparison, _, err := gh.fetchComparison(fmt.Sprintf("%s/repos/%s/compare/%s...%s?per_page=1", gh.client.baseURL, repoName, base, head))
+	return comparison, err
+}
+
+// fetchComparison fetches one page of a comparison and the URL of the next one
+func (gh *GitHubAPI) fetchComparison(url string) (*models.CompareResponse, string, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := gh.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	var page models.CompareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, "", decodeError(err)
+	}
+	return &page, utils.ParseLinkHeader(resp.Header.Get("Link"))["next"], nil
+}
+
+// setInstallation records which App installation serves repo, when authenticating as an App
+func (gh *GitHubAPI) setInstallation(repo *models.Repository) {
+	app := gh.client.tokens.app
+	if app == nil {
+		return
+	}
+	if id, err := app.InstallationID(repo.FullName); err == nil {
+		repo.InstallationID = id
+	}
+}
+
+// FetchCommits pages through the commit list, handing each page to handle as soon
+// as it arrives so nothing accumulates in memory. The cursor passed along is the
+// SHA to resume from once that page is stored.
+func (gh *GitHubAPI) FetchCommits(repoName string, repoId uint, config models.CommitConfig, handle ports.CommitPageHandler) error {
+	url := utils.BuildGHCommitURL(gh.client.baseURL, repoName, config)
+
+	// only the first page is conditional: a 304 there means nothing new was pushed
+	firstPage := gh.client.conditional(repoName, false)
+	var doer utils.HTTPDoer = firstPage
 
 	gh.logger.Sugar().Info("Fetching Commit in Batches...")
-	for len(allCommits) < 1000 {
-		commits, nextURL, rL, err := utils.FetchBatch(url, gh.token)
+	total := 0
+	for {
+		commits, nextURL, err := utils.FetchBatch(doer, url)
+		var batchErr *utils.DecodeError
+		if errors.As(err, &batchErr) {
+			return &DecodeError{Err: batchErr.Err}
+		}
 		if err != nil {
-			errL = err
-			break
+			return err
 		}
 
-		if config.Sha != "" && len(commits) > 0 {
+		if config.Sha != "" && doer == firstPage && len(commits) > 0 {
 			// remove already fetch hash from hash
 			commits = commits[1:]
 		}
-		allCommits = append(allCommits, commits...)
-		if rL != 0 {
-			rateLimitDuration = rL
-			break
+		if len(commits) > 0 {
+			commitsMd := make([]models.Commit, 0, len(commits))
+			for _, cmt := range commits {
+				commitsMd = append(commitsMd, cmt.ToCommit(repoId))
+			}
+			if err := handle(commitsMd, commitsMd[len(commitsMd)-1].Hash); err != nil {
+				return err
+			}
+			total += len(commitsMd)
 		}
-		if len(commits) == 0 || nextURL == "" {
+		if doer == firstPage {
+			if err := firstPage.commit(); err != nil {
+				gh.logger.Sugar().Warn("FetchCommits cache Error, " + err.Error())
+			}
+		}
+		if nextURL == "" {
 			break
 		}
 		url = nextURL
+		doer = gh.client
 	}
-	var commitsMd []models.Commit
-	for _, cmt := range allCommits {
-		commitsMd = append(commitsMd, cmt.ToCommit(repoId))
-	}
-	gh.logger.Sugar().Info("Total Commits in Current Batch: ", len(commitsMd))
-
-	lastCommitSHA := ""
-	if len(allCommits) > 0 {
-		lastCommitSHA = allCommits[len(allCommits)-1].SHA
-	}
-
-	return commitsMd, lastCommitSHA, rateLimitDuration, errL
+	gh.logger.Sugar().Info("Total Commits Fetched: ", total)
+	return nil
 }
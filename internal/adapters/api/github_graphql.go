@@ -0,0 +1,282 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"go.uber.org/zap"
+)
+
+// historyQuery pages through the history of a commit, 100 at a time. The
+// expression is a SHA to resume from, a branch ref, or HEAD for the default branch.
+const historyQuery = `query($owner: String!, $name: String!, $expression: String!, $since: GitTimestamp, $until: GitTimestamp, $after: String) {
+  rateLimit { cost limit remaining used resetAt }
+  repository(owner: $owner, name: $name) {
+    object(expression: $expression) {
+      ... on Commit {
+        history(first: 100, since: $since, until: $until, after: $after) {
+          pageInfo { hasNextPage endCursor }
+          nodes {
+            oid
+            message
+            additions
+            deletions
+            author { name email date user { login databaseId } }
+            committer { name email date user { login databaseId } }
+            parents(first: 10) { nodes { oid } }
+            signature { isValid state }
+            comments { totalCount }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type graphqlActor struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+	User  *struct {
+		Login      string `json:"login"`
+		DatabaseID int64  `json:"databaseId"`
+	} `json:"user"`
+}
+
+func (a graphqlActor) signature() models.CommitSignature {
+	return models.CommitSignature{Name: a.Name, Email: a.Email, Date: a.Date}
+}
+
+func (a graphqlActor) githubUser() *models.GitHubUser {
+	if a.User == nil {
+		return nil
+	}
+	return &models.GitHubUser{Login: a.User.Login, ID: a.User.DatabaseID}
+}
+
+type graphqlCommit struct {
+	OID       string       `json:"oid"`
+	Message   string       `json:"message"`
+	Additions int          `json:"additions"`
+	Deletions int          `json:"deletions"`
+	Author    graphqlActor `json:"author"`
+	Committer graphqlActor `json:"committer"`
+	Parents   struct {
+		Nodes []struct {
+			OID string `json:"oid"`
+		} `json:"nodes"`
+	} `json:"parents"`
+	Signature *struct {
+		IsValid bool   `json:"isValid"`
+		State   string `json:"state"`
+	} `json:"signature"`
+	Comments struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"comments"`
+}
+
+type historyResponse struct {
+	Data struct {
+		RateLimit struct {
+			Cost      int       `json:"cost"`
+			Limit     int       `json:"limit"`
+			Remaining int       `json:"remaining"`
+			Used      int       `json:"used"`
+			ResetAt   time.Time `json:"resetAt"`
+		} `json:"rateLimit"`
+		Repository *struct {
+			Object *struct {
+				History struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []graphqlCommit `json:"nodes"`
+				} `json:"history"`
+			} `json:"object"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GraphQLAPI fetches commit history through the GraphQL v4 API, which returns
+// stats, committer and parents with each page. Everything else is served by
+// the REST adapter it embeds.
+type GraphQLAPI struct {
+	*GitHubAPI
+	endpoint string
+}
+
+func NewGraphQLAPI(opts Options, logger *zap.Logger) ports.GithubService {
+	rest := &GitHubAPI{client: newClient(opts, logger), logger: logger}
+	return &GraphQLAPI{GitHubAPI: rest, endpoint: graphqlEndpoint(rest.client.baseURL)}
+}
+
+// graphqlEndpoint derives the GraphQL URL from the REST root:
+// https://api.github.com -> /graphql, https://ghes/api/v3 -> https://ghes/api/graphql
+func graphqlEndpoint(baseURL string) string {
+	if strings.HasSuffix(baseURL, "/api/v3") {
+		return strings.TrimSuffix(baseURL, "/v3") + "/graphql"
+	}
+	return baseURL + "/graphql"
+}
+
+func (gq *GraphQLAPI) FetchCommits(repoName string, repoId uint, config models.CommitConfig, handle ports.CommitPageHandler) error {
+	owner, name, found := strings.Cut(repoName, "/")
+	if !found {
+		return fmt.Errorf("invalid repository name: %s", repoName)
+	}
+	expression := "HEAD"
+	if config.Sha != "" {
+		expression = config.Sha
+	} else if config.Branch != "" {
+		expression = "refs/heads/" + config.Branch
+	}
+	variables := map[string]interface{}{
+		"owner":      owner,
+		"name":       name,
+		"expression": expression,
+	}
+	if since := gitTimestamp(config.StartDate); since != "" {
+		variables["since"] = since
+	}
+	if until := gitTimestamp(config.EndDate); until != "" {
+		variables["until"] = until
+	}
+
+	gq.logger.Sugar().Info("Fetching Commit in Batches via GraphQL...")
+	total := 0
+	for {
+		page, err := gq.history(repoName, variables)
+		if err != nil {
+			return err
+		}
+		if page.Data.Repository == nil || page.Data.Repository.Object == nil {
+			// a missing branch or resume commit, reported like the REST fetcher does
+			return &StatusError{URL: gq.endpoint, StatusCode: http.StatusNotFound,
+				Message: fmt.Sprintf("no commit %v in %s", variables["expression"], repoName)}
+		}
+		history := page.Data.Repository.Object.History
+		nodes := history.Nodes
+		if config.Sha != "" && variables["after"] == nil && len(nodes) > 0 {
+			// the resume commit itself was stored by the previous batch
+			nodes = nodes[1:]
+		}
+		if len(nodes) > 0 {
+			commitsMd := make([]models.Commit, 0, len(nodes))
+			for _, node := range nodes {
+				cmt := gq.toCommitResponse(repoName, node)
+				commitsMd = append(commitsMd, cmt.ToCommit(repoId))
+			}
+			if err := handle(commitsMd, commitsMd[len(commitsMd)-1].Hash); err != nil {
+				return err
+			}
+			total += len(commitsMd)
+		}
+		if !history.PageInfo.HasNextPage || len(history.Nodes) == 0 {
+			break
+		}
+		variables["after"] = history.PageInfo.EndCursor
+	}
+	gq.logger.Sugar().Info("Total Commits Fetched: ", total)
+	return nil
+}
+
+func (gq *GraphQLAPI) history(repoName string, variables map[string]interface{}) (*historyResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{"query": historyQuery, "variables": variables})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", gq.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	used := &usedToken{}
+	req = req.WithContext(withUsedToken(withRepo(req.Context(), repoName), used))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := gq.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var page historyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, decodeError(err)
+	}
+	rl := page.Data.RateLimit
+	gq.logger.Sugar().Debugf("GraphQL query cost %d points, %d/%d remaining until %s", rl.Cost, rl.Remaining, rl.Limit, rl.ResetAt)
+	if used.token != nil && rl.Limit > 0 {
+		// the points left pace the following queries even without rate limit headers
+		used.token.limiter.ObserveBudget("graphql", rl.Limit, rl.Remaining, rl.Used, rl.ResetAt)
+	}
+	if len(page.Errors) > 0 {
+		return nil, graphqlError(gq.endpoint, page.Errors[0].Type, page.Errors[0].Message, rl.ResetAt)
+	}
+	return &page, nil
+}
+
+// graphqlError maps GraphQL error types onto the adapter's typed errors
+func graphqlError(url, errType, message string, resetAt time.Time) error {
+	statusErr := StatusError{URL: url, Message: message, StatusCode: http.StatusOK}
+	switch errType {
+	case "NOT_FOUND":
+		statusErr.StatusCode = http.StatusNotFound
+	case "FORBIDDEN":
+		statusErr.StatusCode = http.StatusForbidden
+	case "RATE_LIMITED":
+		statusErr.StatusCode = http.StatusForbidden
+		return &RateLimitError{StatusError: statusErr, Reset: resetAt}
+	}
+	return &statusErr
+}
+
+// toCommitResponse reshapes a GraphQL node as the REST list endpoint returns it,
+// so both fetchers store identical rows
+func (gq *GraphQLAPI) toCommitResponse(repoName string, node graphqlCommit) models.CommitResponse {
+	var cmt models.CommitResponse
+	cmt.SHA = node.OID
+	cmt.URL = fmt.Sprintf("%s/repos/%s/commits/%s", gq.client.baseURL, repoName, node.OID)
+	cmt.Commit.Author = node.Author.signature()
+	cmt.Commit.Committer = node.Committer.signature()
+	cmt.Commit.Message = node.Message
+	cmt.Commit.CommentCount = node.Comments.TotalCount
+	// REST reports the same signature states in lower case
+	cmt.Commit.Verification.Reason = "unsigned"
+	if node.Signature != nil {
+		cmt.Commit.Verification.Verified = node.Signature.IsValid
+		cmt.Commit.Verification.Reason = strings.ToLower(node.Signature.State)
+	}
+	cmt.Commit.URL = fmt.Sprintf("%s/repos/%s/git/commits/%s", gq.client.baseURL, repoName, node.OID)
+	cmt.Author = node.Author.githubUser()
+	cmt.Committer = node.Committer.githubUser()
+	for _, parent := range node.Parents.Nodes {
+		cmt.Parents = append(cmt.Parents, models.CommitParent{SHA: parent.OID})
+	}
+	cmt.Stats = &models.CommitStats{
+		Additions: node.Additions,
+		Deletions: node.Deletions,
+		Total:     node.Additions + node.Deletions,
+	}
+	return cmt
+}
+
+// gitTimestamp accepts the YYYY-MM-DD dates used in config as well as full timestamps
+func gitTimestamp(date string) string {
+	if date == "" {
+		return ""
+	}
+	if t, err := time.Parse("2006-01-02", date); err == nil {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return date
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+)
+
+// FetchIssues pages through the issues of a repository updated since since,
+// oldest update first, handing each page to handle. The issues endpoint lists
+// pull requests too; they are dropped.
+func (gh *GitHubAPI) FetchIssues(repoName string, repoID uint, since *time.Time, handle ports.IssuePageHandler) error {
+	url := fmt.Sprintf("%s/repos/%s/issues?state=all&sort=updated&direction=asc&per_page=100", gh.client.baseURL, repoName)
+	if since != nil {
+		url += "&since=" + since.UTC().Format(time.RFC3339)
+	}
+	total := 0
+	err := gh.fetchPages(url, func(body io.Reader) error {
+		var page []models.IssueResponse
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return err
+		}
+		issues := make([]models.Issue, 0, len(page))
+		for _, i := range page {
+			if !i.IsPullRequest() {
+				issues = append(issues, i.ToIssue(repoID))
+			}
+		}
+		if len(issues) == 0 {
+			return nil
+		}
+		total += len(issues)
+		return handle(issues)
+	})
+	if err != nil {
+		return err
+	}
+	gh.logger.Sugar().Info("Total Issues Fetched: ", total)
+	return nil
+}
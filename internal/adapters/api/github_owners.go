@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+)
+
+// ListOwnerRepositories lists the repositories of an organization or user,
+// reporting which of the two the owner is. Organization listings include the
+// private repositories the credentials can see; user listings only the public
+// ones, unless authenticated as that user. In App mode the requests use the
+// owner's installation token, so they see what the App is installed on.
+func (gh *GitHubAPI) ListOwnerRepositories(owner string) ([]models.Repository, bool, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/users/%s", gh.client.baseURL, owner), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := gh.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	var account struct {
+		Type string `json:"type"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&account)
+	resp.Body.Close()
+	if err != nil {
+		return nil, false, decodeError(err)
+	}
+
+	isOrg := account.Type == "Organization"
+	url := fmt.Sprintf("%s/users/%s/repos?type=owner&per_page=100", gh.client.baseURL, owner)
+	if isOrg {
+		url = fmt.Sprintf("%s/orgs/%s/repos?type=all&per_page=100", gh.client.baseURL, owner)
+	}
+	var repos []models.Repository
+	err = gh.fetchPages(url, func(body io.Reader) error {
+		var page []models.Repository
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return err
+		}
+		for i := range page {
+			gh.setInstallation(&page[i])
+		}
+		repos = append(repos, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return repos, isOrg, nil
+}
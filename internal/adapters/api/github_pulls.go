@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// FetchPullRequests pages through the pull requests of a repository, most
+// recently updated first, handing each page to handle. Paging stops at the
+// first pull request last updated before since, as every later one is older.
+func (gh *GitHubAPI) FetchPullRequests(repoName string, repoID uint, since *time.Time, handle ports.PullRequestPageHandler) error {
+	url := fmt.Sprintf("%s/repos/%s/pulls?state=all&sort=updated&direction=desc&per_page=100", gh.client.baseURL, repoName)
+	total := 0
+	for url != "" {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := gh.client.Do(req)
+		if err != nil {
+			return err
+		}
+		var page []models.PullRequestResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return decodeError(err)
+		}
+		url = utils.ParseLinkHeader(resp.Header.Get("Link"))["next"]
+
+		prs := make([]models.PullRequest, 0, len(page))
+		for _, p := range page {
+			if since != nil && p.UpdatedAt.Before(*since) {
+				url = ""
+				break
+			}
+			prs = append(prs, p.ToPullRequest(repoID))
+		}
+		if len(prs) > 0 {
+			if err := handle(prs); err != nil {
+				return err
+			}
+			total += len(prs)
+		}
+	}
+	gh.logger.Sugar().Info("Total Pull Requests Fetched: ", total)
+	return nil
+}
+
+func (gh *GitHubAPI) FetchPullRequest(repoName string, number int) (*models.PullRequestResponse, error) {
+	url := fmt.Sprintf("%s/repos/%s/pulls/%d", gh.client.baseURL, repoName, number)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := gh.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var pr models.PullRequestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return nil, decodeError(err)
+	}
+	return &pr, nil
+}
+
+func (gh *GitHubAPI) FetchPullRequestReviews(repoName string, repoID uint, number int) ([]models.PullRequestReview, error) {
+	url := fmt.Sprintf("%s/repos/%s/pulls/%d/reviews?per_page=100", gh.client.baseURL, repoName, number)
+	var reviews []models.PullRequestReview
+	err := gh.fetchPages(url, func(body io.Reader) error {
+		var page []models.ReviewResponse
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return err
+		}
+		for _, r := range page {
+			reviews = append(reviews, r.ToReview(repoID, number))
+		}
+		return nil
+	})
+	return reviews, err
+}
+
+func (gh *GitHubAPI) FetchReviewComments(repoName string, repoID uint, number int) ([]models.ReviewComment, error) {
+	url := fmt.Sprintf("%s/repos/%s/pulls/%d/comments?per_page=100", gh.client.baseURL, repoName, number)
+	var comments []models.ReviewComment
+	err := gh.fetchPages(url, func(body io.Reader) error {
+		var page []models.ReviewCommentResponse
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return err
+		}
+		for _, c := range page {
+			comments = append(comments, c.ToReviewComment(repoID, number))
+		}
+		return nil
+	})
+	return comments, err
+}
+
+// fetchPages hands the body of url and of every following page to decode.
+// Decoding errors are reported as ErrDecode.
+func (gh *GitHubAPI) fetchPages(url string, decode func(body io.Reader) error) error {
+	for url != "" {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := gh.client.Do(req)
+		if err != nil {
+			return err
+		}
+		err = decode(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return decodeError(err)
+		}
+		url = utils.ParseLinkHeader(resp.Header.Get("Link"))["next"]
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+)
+
+// releasePageSize is the page size of tag and release listings, which are
+// walked by page number
+const releasePageSize = 100
+
+// FetchTags hands every tag of repoName to handle when any page of them changed since the last run
+func (gh *GitHubAPI) FetchTags(repoName string, repoID uint, handle ports.TagListHandler) error {
+	url := fmt.Sprintf("%s/repos/%s/tags?per_page=%d", gh.client.baseURL, repoName, releasePageSize)
+	var tags []models.Tag
+	return gh.fetchChangedPages(repoName, url, func(body io.Reader) error {
+		var page []models.TagResponse
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return err
+		}
+		for _, t := range page {
+			tags = append(tags, t.ToTag(repoID))
+		}
+		return nil
+	}, func() error {
+		return handle(tags)
+	})
+}
+
+// FetchReleases hands every release of repoName to handle when any page of them changed since the last run
+func (gh *GitHubAPI) FetchReleases(repoName string, repoID uint, handle ports.ReleaseListHandler) error {
+	url := fmt.Sprintf("%s/repos/%s/releases?per_page=%d", gh.client.baseURL, repoName, releasePageSize)
+	var releases []models.Release
+	return gh.fetchChangedPages(repoName, url, func(body io.Reader) error {
+		var page []models.ReleaseResponse
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return err
+		}
+		for _, r := range page {
+			releases = append(releases, r.ToRelease(repoID))
+		}
+		return nil
+	}, func() error {
+		return handle(releases)
+	})
+}
+
+// fetchChangedPages requests every page of url conditionally. Tags are not
+// ordered by recency and older releases can be edited, so a change may show on
+// any page: when one of them changed, all pages, the unchanged ones answered
+// from the cache, are handed to decode and store is called. When none changed
+// store is not called. Pages are walked by number since a 304 carries no Link
+// header, and their validators are only saved once store succeeded.
+func (gh *GitHubAPI) fetchChangedPages(repoName string, url string, decode func(body io.Reader) error, store func() error) error {
+	var pages []*conditionalDoer
+	var bodies [][]byte
+	changed := false
+	for page := 1; ; page++ {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s&page=%d", url, page), nil)
+		if err != nil {
+			return err
+		}
+		doer := gh.client.conditional(repoName, true)
+		resp, err := doer.Do(req)
+		if err != nil {
+			return err
+		}
+		var body []byte
+		if resp.StatusCode == http.StatusNotModified && doer.cached != nil {
+			body = doer.cached.Body
+		} else {
+			changed = true
+			body, err = io.ReadAll(resp.Body)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			return decodeError(err)
+		}
+		pages = append(pages, doer)
+		bodies = append(bodies, body)
+		if len(items) < releasePageSize {
+			break
+		}
+	}
+	if !changed {
+		return nil
+	}
+	for _, body := range bodies {
+		if err := decode(bytes.NewReader(body)); err != nil {
+			return decodeError(err)
+		}
+	}
+	if err := store(); err != nil {
+		return err
+	}
+	for _, page := range pages {
+		if err := page.commit(); err != nil {
+			gh.logger.Sugar().Warn("fetchChangedPages cache Error, " + err.Error())
+		}
+	}
+	return nil
+}
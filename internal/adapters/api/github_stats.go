@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+)
+
+func (gh *GitHubAPI) FetchContributorStats(repoName string, repoID uint) ([]models.ContributorWeek, error) {
+	url := fmt.Sprintf("%s/repos/%s/stats/contributors", gh.client.baseURL, repoName)
+	var contributors []models.ContributorStatsResponse
+	if err := gh.fetchStats(url, &contributors); err != nil {
+		return nil, err
+	}
+	var weeks []models.ContributorWeek
+	for _, c := range contributors {
+		weeks = append(weeks, c.ToContributorWeeks(repoID)...)
+	}
+	return weeks, nil
+}
+
+func (gh *GitHubAPI) FetchCommitActivity(repoName string, repoID uint) ([]models.CommitActivityWeek, error) {
+	url := fmt.Sprintf("%s/repos/%s/stats/commit_activity", gh.client.baseURL, repoName)
+	var activity []models.CommitActivityResponse
+	if err := gh.fetchStats(url, &activity); err != nil {
+		return nil, err
+	}
+	weeks := make([]models.CommitActivityWeek, 0, len(activity))
+	for _, a := range activity {
+		weeks = append(weeks, a.ToCommitActivityWeek(repoID))
+	}
+	return weeks, nil
+}
+
+// fetchStats decodes a statistics endpoint into v. GitHub answers 202 while it
+// computes statistics that are not cached; the request is repeated with the
+// retry backoff and ErrComputing returned if they are still not ready. A 204
+// means the repository has no commits and leaves v empty.
+func (gh *GitHubAPI) fetchStats(url string, v interface{}) error {
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := gh.client.Do(req)
+		if err != nil {
+			return err
+		}
+		switch resp.StatusCode {
+		case http.StatusAccepted:
+			resp.Body.Close()
+			if attempt >= gh.client.retry.MaxAttempts {
+				return fmt.Errorf("%w: %s", ErrComputing, url)
+			}
+			delay := gh.client.retry.backoff(attempt)
+			gh.logger.Sugar().Infof("GitHub is computing %s, asking again in %s", url, delay.Round(time.Millisecond))
+			time.Sleep(delay)
+			continue
+		case http.StatusNoContent:
+			resp.Body.Close()
+			return nil
+		}
+		err = json.NewDecoder(resp.Body).Decode(v)
+		resp.Body.Close()
+		if err != nil {
+			return decodeError(err)
+		}
+		return nil
+	}
+}
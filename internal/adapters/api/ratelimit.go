@@ -0,0 +1,215 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultResource = "core"
+	// fraction of the quota below which requests are spread out until reset
+	defaultSlowdownThreshold = 0.1
+	// GitHub asks clients to wait at least a minute on a secondary limit without Retry-After
+	secondaryLimitWait = time.Minute
+)
+
+// RateLimiter tracks the quota of one credential and is shared by every request
+// made with it. It records what GitHub reports on each response, paces requests
+// as the quota runs low and blocks all callers once a primary or secondary limit is hit.
+type RateLimiter struct {
+	mu                sync.Mutex
+	resources         map[string]*types.RateLimitStatus
+	blockedUntil      time.Time
+	slowdownThreshold float64
+	logger            *zap.Logger
+}
+
+func NewRateLimiter(slowdownThreshold float64, logger *zap.Logger) *RateLimiter {
+	if slowdownThreshold <= 0 {
+		slowdownThreshold = defaultSlowdownThreshold
+	}
+	return &RateLimiter{
+		resources:         make(map[string]*types.RateLimitStatus),
+		slowdownThreshold: slowdownThreshold,
+		logger:            logger,
+	}
+}
+
+// Wait blocks until a request against resource may be sent
+func (rl *RateLimiter) Wait(resource string) {
+	if delay := rl.delay(resource, time.Now()); delay > 0 {
+		rl.logger.Sugar().Infof("Rate limiter holding %s request for %s", resource, delay.Round(time.Millisecond))
+		time.Sleep(delay)
+	}
+}
+
+func (rl *RateLimiter) delay(resource string, now time.Time) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	blockedUntil := rl.blockedUntil
+	status, ok := rl.resources[resource]
+	if ok && status.BlockedUntil.After(blockedUntil) {
+		blockedUntil = status.BlockedUntil
+	}
+	if blockedUntil.After(now) {
+		return blockedUntil.Sub(now)
+	}
+	if !ok || status.Limit == 0 || !status.Reset.After(now) {
+		return 0
+	}
+	if float64(status.Remaining) >= float64(status.Limit)*rl.slowdownThreshold {
+		return 0
+	}
+	// spread what is left evenly over the time until reset
+	return status.Reset.Sub(now) / time.Duration(status.Remaining+1)
+}
+
+// available returns the remaining quota for resource (-1 when not yet known)
+// and the time until which requests against it are blocked
+func (rl *RateLimiter) available(resource string) (int, time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	remaining, blockedUntil := -1, rl.blockedUntil
+	if status, ok := rl.resources[resource]; ok {
+		if status.Limit > 0 || status.BlockedUntil.After(blockedUntil) {
+			remaining = status.Remaining
+		}
+		if status.BlockedUntil.After(blockedUntil) {
+			blockedUntil = status.BlockedUntil
+		}
+	}
+	return remaining, blockedUntil
+}
+
+// Observe records the X-RateLimit-* headers of a response
+func (rl *RateLimiter) Observe(resp *http.Response) {
+	limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	used, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Used"))
+	reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = defaultResource
+	}
+	rl.ObserveBudget(resource, limit, remaining, used, time.Unix(reset, 0))
+}
+
+// ObserveBudget records the quota of resource as reported in a response body,
+// such as the points left that the GraphQL API returns with every query
+func (rl *RateLimiter) ObserveBudget(resource string, limit, remaining, used int, reset time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	status, ok := rl.resources[resource]
+	if !ok {
+		status = &types.RateLimitStatus{Resource: resource}
+		rl.resources[resource] = status
+	}
+	status.Limit = limit
+	status.Remaining = remaining
+	status.Used = used
+	status.Reset = reset
+}
+
+// Limited reports whether resp was rejected by a primary or secondary rate limit,
+// and if so blocks further requests until GitHub allows them again.
+func (rl *RateLimiter) Limited(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	now := time.Now()
+
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		seconds, err := strconv.Atoi(retryAfter)
+		if err != nil {
+			seconds = int(secondaryLimitWait.Seconds())
+		}
+		rl.block("", now.Add(time.Duration(seconds)*time.Second))
+		return true
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		until := now.Add(secondaryLimitWait)
+		if err == nil {
+			until = time.Unix(reset, 0)
+		}
+		resource := resp.Header.Get("X-RateLimit-Resource")
+		if resource == "" {
+			resource = defaultResource
+		}
+		rl.block(resource, until)
+		return true
+	}
+
+	// secondary limits without headers are only recognisable from the message
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err == nil && strings.Contains(strings.ToLower(string(body)), "secondary rate limit") {
+		rl.block("", now.Add(secondaryLimitWait))
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests
+}
+
+// block holds back requests for resource, or for every resource when it is empty
+func (rl *RateLimiter) block(resource string, until time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if resource == "" {
+		if until.After(rl.blockedUntil) {
+			rl.blockedUntil = until
+		}
+		rl.logger.Sugar().Warn("Secondary rate limit hit, blocking requests until ", until)
+		return
+	}
+	status, ok := rl.resources[resource]
+	if !ok {
+		status = &types.RateLimitStatus{Resource: resource}
+		rl.resources[resource] = status
+	}
+	status.Remaining = 0
+	status.BlockedUntil = until
+	rl.logger.Sugar().Warnf("Primary rate limit for %s exhausted, blocking requests until %s", resource, until)
+}
+
+// Status returns a snapshot of every resource seen so far
+func (rl *RateLimiter) Status() []types.RateLimitStatus {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	statuses := make([]types.RateLimitStatus, 0, len(rl.resources))
+	for _, status := range rl.resources {
+		s := *status
+		if rl.blockedUntil.After(s.BlockedUntil) {
+			s.BlockedUntil = rl.blockedUntil
+		}
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Resource < statuses[j].Resource })
+	return statuses
+}
+
+// resourceFor guesses which quota a request is charged against before GitHub says so
+func resourceFor(req *http.Request) string {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/graphql"):
+		return "graphql"
+	case strings.Contains(req.URL.Path, "/search/"):
+		return "search"
+	default:
+		return defaultResource
+	}
+}
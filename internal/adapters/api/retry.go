@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+)
+
+// RetryPolicy controls how transient failures (connection errors, 5xx responses
+// and truncated bodies) are retried. Zero fields fall back to the defaults.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter is the fraction (0-1) of each delay that is randomised
+	Jitter float64
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+	Jitter:      0.5,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		p.Jitter = DefaultRetryPolicy.Jitter
+	}
+	return p
+}
+
+// backoff returns the delay before retry number attempt (1-based)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	jitter := time.Duration(float64(delay) * p.Jitter * rand.Float64())
+	return delay - time.Duration(float64(delay)*p.Jitter/2) + jitter
+}
+
+// retryable classifies the outcome of one attempt. Connection errors, 5xx
+// responses and truncated bodies are retried. Everything else is final,
+// including 401, 404, 422 and other 4xx responses returned as typed errors by
+// the App installation lookup.
+func retryable(resp *http.Response, err error) (bool, string) {
+	if err != nil {
+		var urlErr *url.Error
+		switch {
+		case errors.Is(err, context.Canceled):
+			return false, ""
+		case errors.Is(err, ErrUpstream), errors.Is(err, errTruncatedBody), errors.As(err, &urlErr):
+			return true, err.Error()
+		}
+		return false, ""
+	}
+	if resp.StatusCode >= 500 {
+		return true, fmt.Sprintf("status %d", resp.StatusCode)
+	}
+	return false, ""
+}
+
+var errTruncatedBody = errors.New("truncated response body")
+
+// bufferBody reads the whole body so a connection dropped mid-response surfaces
+// here, where it can be retried, rather than as a decode error in the caller
+func bufferBody(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errTruncatedBody, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
+
+// rewind restores the body of a request that is about to be sent again
+func rewind(req *http.Request) error {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+// retryCounter counts retries so flaky upstreams can be alerted on
+type retryCounter struct {
+	mu    sync.Mutex
+	stats types.RetryStats
+}
+
+func (r *retryCounter) retried(url, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Retries++
+	r.stats.LastURL = url
+	r.stats.LastReason = reason
+	r.stats.LastRetryAt = time.Now()
+}
+
+func (r *retryCounter) exhausted() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Exhausted++
+}
+
+func (r *retryCounter) snapshot() types.RetryStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"go.uber.org/zap"
+)
+
+var errNoUsableToken = fmt.Errorf("%w: all configured tokens are revoked", ErrUnauthorized)
+
+// credential supplies the token sent in the Authorization header
+type credential interface {
+	// ID identifies the credential in logs and status output without leaking it
+	ID() string
+	Token() (string, error)
+}
+
+type staticToken string
+
+func (t staticToken) ID() string {
+	return maskToken(string(t))
+}
+
+func (t staticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+// maskToken keeps the token prefix and last four characters, e.g. ghp_…a1b2
+func maskToken(token string) string {
+	if token == "" {
+		return "anonymous"
+	}
+	prefix := ""
+	if i := strings.Index(token, "_"); i >= 0 && i < 12 {
+		prefix = token[:i+1]
+	}
+	if len(token) <= 8 {
+		return prefix + "…"
+	}
+	return prefix + "…" + token[len(token)-4:]
+}
+
+// poolToken is one credential with its own quota tracking
+type poolToken struct {
+	cred        credential
+	limiter     *RateLimiter
+	lastError   string
+	lastErrorAt time.Time
+	revoked     bool
+}
+
+func (t *poolToken) authorize(req *http.Request) error {
+	token, err := t.cred.Token()
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		req.Header.Del("Authorization")
+		return nil
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+type usedTokenContextKey struct{}
+
+// usedToken receives the token a request was sent with, for callers that learn
+// about its quota from the response body
+type usedToken struct {
+	token *poolToken
+}
+
+func withUsedToken(ctx context.Context, used *usedToken) context.Context {
+	return context.WithValue(ctx, usedTokenContextKey{}, used)
+}
+
+// invalidator is implemented by credentials that can be refreshed after a 401
+// instead of being revoked
+type invalidator interface {
+	invalidate()
+}
+
+// TokenPool rotates requests across several credentials, always picking the one
+// with the most quota left and parking exhausted ones until their reset time.
+// With a GitHub App configured, repository requests use that repository's
+// installation token instead.
+type TokenPool struct {
+	mu                sync.Mutex
+	tokens            []*poolToken
+	app               *AppAuth
+	installations     map[int64]*poolToken
+	slowdownThreshold float64
+	logger            *zap.Logger
+}
+
+func newTokenPool(creds []credential, app *AppAuth, slowdownThreshold float64, logger *zap.Logger) *TokenPool {
+	pool := &TokenPool{
+		app:               app,
+		installations:     make(map[int64]*poolToken),
+		slowdownThreshold: slowdownThreshold,
+		logger:            logger,
+	}
+	if len(creds) == 0 {
+		creds = []credential{staticToken("")}
+	}
+	for _, cred := range creds {
+		pool.tokens = append(pool.tokens, &poolToken{
+			cred:    cred,
+			limiter: NewRateLimiter(slowdownThreshold, logger),
+		})
+	}
+	return pool
+}
+
+// pick returns the credential for a request against repoName, or against the
+// account when repoName is only an owner. In App mode that is the installation
+// token of the repository's owner. Otherwise it is the usable token with the
+// most remaining quota for resource; when every token is parked the one that
+// resets first is returned and its limiter blocks the caller until then.
+func (p *TokenPool) pick(resource, repoName string) (*poolToken, error) {
+	if p.app != nil && repoName != "" {
+		return p.installation(repoName)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var best, earliest *poolToken
+	bestRemaining := -1
+	var earliestUntil time.Time
+	for _, t := range p.tokens {
+		if t.revoked {
+			continue
+		}
+		remaining, blockedUntil := t.limiter.available(resource)
+		if blockedUntil.After(now) {
+			if earliest == nil || blockedUntil.Before(earliestUntil) {
+				earliest, earliestUntil = t, blockedUntil
+			}
+			continue
+		}
+		if remaining < 0 {
+			// nothing observed yet, so assume a full quota
+			remaining = math.MaxInt32
+		}
+		if remaining > bestRemaining {
+			best, bestRemaining = t, remaining
+		}
+	}
+	if best == nil {
+		best = earliest
+	}
+	if best == nil {
+		return nil, errNoUsableToken
+	}
+	return best, nil
+}
+
+func (p *TokenPool) installation(repoName string) (*poolToken, error) {
+	id, err := p.app.InstallationID(repoName)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t, ok := p.installations[id]
+	if !ok {
+		owner := strings.SplitN(repoName, "/", 2)[0]
+		t = &poolToken{
+			cred:    installationCredential{app: p.app, id: id, owner: owner},
+			limiter: NewRateLimiter(p.slowdownThreshold, p.logger),
+		}
+		p.installations[id] = t
+	}
+	return t, nil
+}
+
+// fail records an error against a token; revoke takes it out of rotation for good
+// unless the credential can be refreshed
+func (p *TokenPool) fail(t *poolToken, message string, revoke bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t.lastError = message
+	t.lastErrorAt = time.Now()
+	if inv, ok := t.cred.(invalidator); ok && revoke {
+		inv.invalidate()
+		return
+	}
+	if revoke && !t.revoked {
+		t.revoked = true
+		p.logger.Sugar().Errorf("GitHub token %s revoked: %s", t.cred.ID(), message)
+	}
+}
+
+// usable counts the tokens that have not been revoked
+func (p *TokenPool) usable() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	count := 0
+	for _, t := range p.all() {
+		if !t.revoked {
+			count++
+		}
+	}
+	return count
+}
+
+// all lists static tokens followed by installation tokens; callers hold p.mu
+func (p *TokenPool) all() []*poolToken {
+	tokens := append([]*poolToken{}, p.tokens...)
+	ids := make([]int64, 0, len(p.installations))
+	for id := range p.installations {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		tokens = append(tokens, p.installations[id])
+	}
+	return tokens
+}
+
+func (p *TokenPool) Status() []types.TokenStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tokens := p.all()
+	statuses := make([]types.TokenStatus, 0, len(tokens))
+	for _, t := range tokens {
+		status := types.TokenStatus{
+			ID:          t.cred.ID(),
+			Remaining:   -1,
+			LastError:   t.lastError,
+			LastErrorAt: t.lastErrorAt,
+			Revoked:     t.revoked,
+		}
+		for _, rl := range t.limiter.Status() {
+			if rl.Resource != defaultResource {
+				continue
+			}
+			status.Limit = rl.Limit
+			status.Remaining = rl.Remaining
+			status.Reset = rl.Reset
+			status.ParkedUntil = rl.BlockedUntil
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (p *TokenPool) RateLimitStatus() []types.RateLimitStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var statuses []types.RateLimitStatus
+	for _, t := range p.all() {
+		for _, rl := range t.limiter.Status() {
+			rl.Token = t.cred.ID()
+			statuses = append(statuses, rl)
+		}
+	}
+	return statuses
+}
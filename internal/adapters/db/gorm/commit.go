@@ -1,10 +1,16 @@
 package gorm
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CommitRepo struct {
@@ -30,9 +36,41 @@ func (c *CommitRepo) FindByHash(hash string) (*models.Commit, error) {
 	return &cmt, nil
 }
 
-func (c *CommitRepo) FindByRepoId(repoId uint, page int, pageSize int) ([]*models.Commit, error) {
+// FindByHashes returns the stored commits of a repository among hashes, in no particular order
+func (c *CommitRepo) FindByHashes(repoID uint, hashes []string) ([]*models.Commit, error) {
+	var found []*models.Commit
+	for start := 0; start < len(hashes); start += sqlBatchSize {
+		end := start + sqlBatchSize
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		var batch []*models.Commit
+		if err := c.db.Where("repo_id = ? AND hash IN ?", repoID, hashes[start:end]).Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		found = append(found, batch...)
+	}
+	return found, nil
+}
+
+func (c *CommitRepo) FindByRepoId(repoId uint, filter types.CommitFilter, page int, pageSize int) ([]*models.Commit, error) {
 	var cmt []*models.Commit
-	if err := c.db.Where("repo_id = ?", repoId).
+	query := c.db.Where("repo_id = ?", repoId)
+	if filter.Merge != nil {
+		query = query.Where("is_merge = ?", *filter.Merge)
+	}
+	if filter.Verified != nil {
+		query = query.Where("verified = ?", *filter.Verified)
+	}
+	if filter.Orphaned != nil {
+		query = query.Where("orphaned = ?", *filter.Orphaned)
+	}
+	if filter.Branch != "" {
+		query = query.Where("id IN (?)", c.db.Model(&models.CommitBranch{}).
+			Select("commit_id").
+			Where("repo_id = ? AND branch = ?", repoId, filter.Branch))
+	}
+	if err := query.
 		Limit(pageSize).
 		Offset((page-1)*pageSize - 1).
 		Find(&cmt).Error; err != nil {
@@ -50,12 +88,298 @@ func (r *CommitRepo) FindAll() ([]*models.Commit, error) {
 }
 
 func (c *CommitRepo) UpsertCommits(commits []models.Commit) error {
-	for _, commit := range commits {
-		if err := c.db.Save(&commit).Error; err != nil {
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		return upsertCommits(tx, commits)
+	})
+}
+
+// UpsertPage stores a page fetched from branch, an empty branch being the
+// default one, records the commits as part of it and moves its backfill
+// watermark to cursor. An empty cursor leaves the stored one alone.
+func (c *CommitRepo) UpsertPage(repoID uint, branch string, commits []models.Commit, cursor string) error {
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		if err := upsertCommits(tx, commits); err != nil {
+			return err
+		}
+		name := branch
+		if name == "" {
+			var repo models.Repository
+			if err := tx.Select("id", "default_branch").Where("id = ?", repoID).Limit(1).Find(&repo).Error; err != nil {
+				return err
+			}
+			name = repo.DefaultBranch
+		}
+		if err := addToBranch(tx, repoID, name, commits); err != nil {
 			return err
 		}
+		if cursor == "" {
+			return nil
+		}
+		updates := map[string]interface{}{"last_commit_sha": cursor}
+		for i := range commits {
+			if commits[i].Hash == cursor {
+				updates["backfill_date"] = commits[i].CommittedAt()
+			}
+		}
+		if branch != "" {
+			if err := ensureBranch(tx, repoID, branch); err != nil {
+				return err
+			}
+			updates["fetched_at"] = time.Now()
+		}
+		return watermarks(tx, repoID, branch).Updates(updates).Error
+	})
+}
+
+// AdvanceHead moves the head watermark of branch, the default one when empty,
+// to head unless a newer commit is already recorded
+func (c *CommitRepo) AdvanceHead(repoID uint, branch string, head models.Commit) error {
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"head_sha": head.Hash, "head_date": head.CommittedAt()}
+		if branch != "" {
+			if err := ensureBranch(tx, repoID, branch); err != nil {
+				return err
+			}
+			updates["fetched_at"] = time.Now()
+		}
+		return watermarks(tx, repoID, branch).
+			Where("head_sha = '' OR head_date IS NULL OR head_date <= ?", head.CommittedAt()).
+			Updates(updates).Error
+	})
+}
+
+// FindNewest returns the newest stored commit of branch that was not orphaned,
+// looking at the whole repository for the default branch
+func (c *CommitRepo) FindNewest(repoID uint, branch string) (*models.Commit, error) {
+	query := c.db.Where("repo_id = ? AND orphaned = ?", repoID, false)
+	if branch != "" {
+		query = query.Where("id IN (?)", c.db.Model(&models.CommitBranch{}).
+			Select("commit_id").
+			Where("repo_id = ? AND branch = ?", repoID, branch))
+	}
+	var cmt models.Commit
+	if err := query.Order("committer_date DESC, date DESC").First(&cmt).Error; err != nil {
+		return nil, err
+	}
+	return &cmt, nil
+}
+
+// watermarks scopes an update to the sync state of branch, the default one when empty
+func watermarks(db *gorm.DB, repoID uint, branch string) *gorm.DB {
+	if branch == "" {
+		return db.Model(&models.Repository{}).Where("id = ?", repoID)
 	}
-	return nil
+	return db.Model(&models.RepositoryBranch{}).Where("repo_id = ? AND name = ?", repoID, branch)
+}
+
+func ensureBranch(db *gorm.DB, repoID uint, branch string) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RepositoryBranch{RepoID: repoID, Name: branch, FetchedAt: time.Now()}).Error
+}
+
+// addToBranch records the membership of stored commits in branch. Only the
+// commits stored for repoID are linked: history a fork shares with its parent
+// belongs to the parent, and listing the fork's branch leaves it out.
+func addToBranch(db *gorm.DB, repoID uint, branch string, commits []models.Commit) error {
+	if branch == "" || len(commits) == 0 {
+		return nil
+	}
+	hashes := make([]string, 0, len(commits))
+	for _, cmt := range commits {
+		hashes = append(hashes, cmt.Hash)
+	}
+	var ids []uint
+	if err := db.Model(&models.Commit{}).Where("repo_id = ? AND hash IN ?", repoID, hashes).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	members := make([]models.CommitBranch, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, models.CommitBranch{CommitID: id, RepoID: repoID, Branch: branch})
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error
+}
+
+// upsertCommits inserts commits, refreshing the stored copy of any hash already present,
+// and records their trailer participants, author identities and the pull requests they merged. Line stats and
+// enrichment state are left alone, as the list endpoint does not return them.
+func upsertCommits(db *gorm.DB, commits []models.Commit) error {
+	if len(commits) == 0 {
+		return nil
+	}
+	// a commit keeps the repository that stored it first, so the history a fork
+	// shares with its parent does not move between them
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"message", "author", "author_email", "date", "author_login", "author_id",
+			"committer_name", "committer_email", "committer_date", "committer_login", "committer_id",
+			"parents", "is_merge", "verified", "verification_reason", "comment_count", "url", "updated_at",
+			// a commit fetched again is reachable again
+			"orphaned", "orphaned_at",
+		}),
+	}).Create(&commits).Error
+	if err != nil {
+		return err
+	}
+	hashes := make([]string, 0, len(commits))
+	for _, cmt := range commits {
+		hashes = append(hashes, cmt.Hash)
+	}
+	// pull requests stored before their merge commit
+	if err := linkMergeCommits(db, db.Where("merge_commit_sha IN ?", hashes)); err != nil {
+		return err
+	}
+	// and workflow runs stored before their head commit
+	if err := linkRunCommits(db, db.Where("head_sha IN ?", hashes)); err != nil {
+		return err
+	}
+	return linkCommits(db, hashes)
+}
+
+// linkCommits derives the data kept alongside stored commits: it replaces
+// their trailer participants and issue references and resolves the identities
+// of authors and participants.
+func linkCommits(db *gorm.DB, hashes []string) error {
+	var stored []models.Commit
+	err := db.Select("id", "hash", "repo_id", "message", "author", "author_email", "author_id", "author_login").
+		Where("hash IN ?", hashes).Find(&stored).Error
+	if err != nil {
+		return err
+	}
+	resolver, err := newIdentityResolver(db)
+	if err != nil {
+		return err
+	}
+
+	for _, cmt := range stored {
+		if _, err := resolver.resolve(newIdentityKey(cmt.Author, cmt.AuthorEmail, cmt.AuthorID), cmt.AuthorLogin); err != nil {
+			return err
+		}
+		for _, trailer := range utils.ParseTrailers(cmt.Message) {
+			if _, err := resolver.resolve(newIdentityKey(trailer.Name, trailer.Email, 0), ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	// identities are only assigned once every author is resolved, as resolving
+	// a later author may have merged the identity an earlier one was given
+	ids := make([]uint, 0, len(stored))
+	authors := make(map[uint][]uint)
+	var participants []models.CommitParticipant
+	for _, cmt := range stored {
+		ids = append(ids, cmt.ID)
+		identityID, _, err := resolver.lookup(newIdentityKey(cmt.Author, cmt.AuthorEmail, cmt.AuthorID))
+		if err != nil {
+			return err
+		}
+		authors[identityID] = append(authors[identityID], cmt.ID)
+		for _, trailer := range utils.ParseTrailers(cmt.Message) {
+			identityID, _, err := resolver.lookup(newIdentityKey(trailer.Name, trailer.Email, 0))
+			if err != nil {
+				return err
+			}
+			participants = append(participants, models.CommitParticipant{
+				CommitID:   cmt.ID,
+				RepoID:     cmt.RepoID,
+				Role:       trailer.Role,
+				Name:       trailer.Name,
+				Email:      trailer.Email,
+				IdentityID: identityID,
+			})
+		}
+	}
+	for identityID, commitIDs := range authors {
+		if err := db.Model(&models.Commit{}).Where("id IN ?", commitIDs).Update("author_identity_id", identityID).Error; err != nil {
+			return err
+		}
+	}
+	if err := db.Where("commit_id IN ?", ids).Delete(&models.CommitParticipant{}).Error; err != nil {
+		return err
+	}
+	if len(participants) > 0 {
+		if err := db.Create(&participants).Error; err != nil {
+			return err
+		}
+	}
+	return linkReferences(db, stored, ids)
+}
+
+// linkReferences replaces the issue references of stored commits with the ones in their messages
+func linkReferences(db *gorm.DB, stored []models.Commit, ids []uint) error {
+	var refs []models.CommitReference
+	for _, cmt := range stored {
+		for _, ref := range utils.ParseIssueReferences(cmt.Message) {
+			refs = append(refs, models.CommitReference{CommitID: cmt.ID, RepoID: cmt.RepoID, Number: ref.Number, Closes: ref.Closes})
+		}
+	}
+	if err := db.Where("commit_id IN ?", ids).Delete(&models.CommitReference{}).Error; err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	return db.Create(&refs).Error
+}
+
+// Reprocess re-derives participants, references and identities for every stored commit,
+// batchSize commits at a time, for rows stored before they were recorded.
+func (c *CommitRepo) Reprocess(batchSize int) (int, error) {
+	return relinkAll(c.db, batchSize)
+}
+
+func relinkAll(db *gorm.DB, batchSize int) (int, error) {
+	processed := 0
+	var batch []models.Commit
+	err := db.Select("id", "hash").FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+		hashes := make([]string, 0, len(batch))
+		for _, cmt := range batch {
+			hashes = append(hashes, cmt.Hash)
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return linkCommits(tx, hashes)
+		}); err != nil {
+			return err
+		}
+		processed += len(batch)
+		return nil
+	}).Error
+	return processed, err
+}
+
+func (c *CommitRepo) FindUnenriched(repoID uint, limit int) ([]models.Commit, error) {
+	var cmt []models.Commit
+	if err := c.db.Where("repo_id = ? AND enriched_at IS NULL", repoID).
+		Order("date DESC").
+		Limit(limit).
+		Find(&cmt).Error; err != nil {
+		return nil, err
+	}
+	return cmt, nil
+}
+
+func (c *CommitRepo) SaveEnrichment(commit *models.Commit, files []models.CommitFile) error {
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		if len(files) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "commit_id"}, {Name: "filename"}},
+				DoUpdates: clause.AssignmentColumns([]string{"status", "additions", "deletions", "changes", "previous_filename", "updated_at"}),
+			}).Create(&files).Error
+			if err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		commit.EnrichedAt = &now
+		return tx.Model(&models.Commit{}).
+			Where("id = ?", commit.ID).
+			Updates(map[string]interface{}{
+				"additions":     commit.Additions,
+				"deletions":     commit.Deletions,
+				"total_changes": commit.TotalChanges,
+				"enriched_at":   now,
+			}).Error
+	})
 }
 
 // Count returns the total number of commits in the database: for logging purpose
@@ -67,15 +391,190 @@ func (c *CommitRepo) Count() (int64, error) {
 	return count, nil
 }
 
-func (c *CommitRepo) GetTopCommitAuthors(page int, pageSize int) ([]types.AuthorCommitsCount, error) {
+// GetTopCommitAuthors ranks identities by commit count, listing every name and
+// email they committed under. Commits not resolved to an identity yet are
+// counted by author name.
+func (c *CommitRepo) GetTopCommitAuthors(page int, pageSize int, includeCoAuthors bool) ([]types.AuthorCommitsCount, error) {
+	credits := c.db.Raw(`SELECT author_identity_id AS identity_id, author FROM commits`)
+	if includeCoAuthors {
+		// co-authors are credited unless they are the commit's author
+		credits = c.db.Raw(`SELECT author_identity_id AS identity_id, author FROM commits
+			UNION ALL
+			SELECT p.identity_id, p.name AS author FROM commit_participants p JOIN commits c ON c.id = p.commit_id
+			WHERE p.role = ? AND (p.identity_id <> c.author_identity_id OR (p.identity_id = 0 AND p.name <> c.author))`,
+			models.RoleCoAuthor)
+	}
 	var results []types.AuthorCommitsCount
-	err := c.db.Model(&models.Commit{}).
-		Select("author, COUNT(*) as commit_count").
-		Group("author").
+	err := c.db.Table("(?) AS credits", credits).
+		Select(`CASE WHEN credits.identity_id = 0 THEN credits.author ELSE identities.name END AS author,
+			COALESCE(identities.email, '') AS email, credits.identity_id, COUNT(*) AS commit_count`).
+		Joins("LEFT JOIN identities ON identities.id = credits.identity_id").
+		Group("credits.identity_id, CASE WHEN credits.identity_id = 0 THEN credits.author ELSE '' END").
 		Order("commit_count DESC").
 		Limit(pageSize).Offset((page - 1) * (pageSize - 1)).Scan(&results).Error
 	if err != nil {
 		return nil, err
 	}
+
+	ids := make([]uint, 0, len(results))
+	for _, result := range results {
+		if result.IdentityID != 0 {
+			ids = append(ids, result.IdentityID)
+		}
+	}
+	var aliases []models.IdentityAlias
+	if err := c.db.Where("identity_id IN ?", ids).Order("id").Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+	for i := range results {
+		for _, alias := range aliases {
+			if alias.IdentityID != results[i].IdentityID {
+				continue
+			}
+			name := fmt.Sprintf("%s <%s>", alias.Name, alias.Email)
+			if !containsString(results[i].Aliases, name) {
+				results[i].Aliases = append(results[i].Aliases, name)
+			}
+		}
+	}
 	return results, nil
 }
+
+// GetCommitAuthors counts the commits of every author and co-author among
+// commitIDs, most commits first. Co-authors are credited as in GetTopCommitAuthors.
+func (c *CommitRepo) GetCommitAuthors(commitIDs []uint) ([]types.AuthorCommitsCount, error) {
+	type authorKey struct {
+		identityID uint
+		author     string
+	}
+	counts := make(map[authorKey]*types.AuthorCommitsCount)
+	for start := 0; start < len(commitIDs); start += sqlBatchSize {
+		end := start + sqlBatchSize
+		if end > len(commitIDs) {
+			end = len(commitIDs)
+		}
+		ids := commitIDs[start:end]
+		credits := c.db.Raw(`SELECT author_identity_id AS identity_id, author FROM commits WHERE id IN ?
+			UNION ALL
+			SELECT p.identity_id, p.name AS author FROM commit_participants p JOIN commits c ON c.id = p.commit_id
+			WHERE p.commit_id IN ? AND p.role = ? AND (p.identity_id <> c.author_identity_id OR (p.identity_id = 0 AND p.name <> c.author))`,
+			ids, ids, models.RoleCoAuthor)
+		var batch []types.AuthorCommitsCount
+		err := c.db.Table("(?) AS credits", credits).
+			Select(`CASE WHEN credits.identity_id = 0 THEN credits.author ELSE identities.name END AS author,
+				COALESCE(identities.email, '') AS email, credits.identity_id, COUNT(*) AS commit_count`).
+			Joins("LEFT JOIN identities ON identities.id = credits.identity_id").
+			Group("credits.identity_id, CASE WHEN credits.identity_id = 0 THEN credits.author ELSE '' END").
+			Scan(&batch).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range batch {
+			key := authorKey{identityID: result.IdentityID}
+			if result.IdentityID == 0 {
+				key.author = result.Author
+			}
+			if existing, ok := counts[key]; ok {
+				existing.CommitCount += result.CommitCount
+				continue
+			}
+			result := result
+			counts[key] = &result
+		}
+	}
+
+	authors := make([]types.AuthorCommitsCount, 0, len(counts))
+	for _, count := range counts {
+		authors = append(authors, *count)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if authors[i].CommitCount != authors[j].CommitCount {
+			return authors[i].CommitCount > authors[j].CommitCount
+		}
+		return authors[i].Author < authors[j].Author
+	})
+	return authors, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlBatchSize bounds the number of SQL variables per statement when commits are passed by the hundreds
+const sqlBatchSize = 500
+
+func (c *CommitRepo) RecordRewrite(rewrite *models.HistoryRewrite, dropped []string) error {
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for start := 0; start < len(dropped); start += sqlBatchSize {
+			end := start + sqlBatchSize
+			if end > len(dropped) {
+				end = len(dropped)
+			}
+			var ids []uint
+			err := tx.Model(&models.Commit{}).
+				Where("repo_id = ? AND hash IN ?", rewrite.RepoID, dropped[start:end]).
+				Pluck("id", &ids).Error
+			if err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			if err := tx.Where("commit_id IN ? AND branch = ?", ids, rewrite.Branch).Delete(&models.CommitBranch{}).Error; err != nil {
+				return err
+			}
+			// commits still on another fetched branch are not orphaned
+			result := tx.Model(&models.Commit{}).
+				Where("id IN ? AND id NOT IN (?)", ids, tx.Model(&models.CommitBranch{}).Select("commit_id")).
+				Updates(map[string]interface{}{"orphaned": true, "orphaned_at": now})
+			if result.Error != nil {
+				return result.Error
+			}
+			rewrite.OrphanedCount += int(result.RowsAffected)
+		}
+
+		var repo models.Repository
+		if err := tx.Select("id", "default_branch").Where("id = ?", rewrite.RepoID).Limit(1).Find(&repo).Error; err != nil {
+			return err
+		}
+		branch := rewrite.Branch
+		if branch == repo.DefaultBranch {
+			branch = ""
+		}
+		// the branch is synced again from scratch
+		err := watermarks(tx, rewrite.RepoID, branch).Updates(map[string]interface{}{
+			"last_commit_sha": "",
+			"backfill_date":   nil,
+			"head_sha":        "",
+			"head_date":       nil,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if rewrite.DetectedAt.IsZero() {
+			rewrite.DetectedAt = now
+		}
+		return tx.Create(rewrite).Error
+	})
+}
+
+func (c *CommitRepo) FindRewrites(repoID uint, protected *bool, page int, pageSize int) ([]models.HistoryRewrite, error) {
+	var rewrites []models.HistoryRewrite
+	query := c.db.Where("repo_id = ?", repoID)
+	if protected != nil {
+		query = query.Where("protected = ?", *protected)
+	}
+	if err := query.
+		Order("detected_at DESC").
+		Scopes(paginate(page, pageSize)).
+		Find(&rewrites).Error; err != nil {
+		return nil, err
+	}
+	return rewrites, nil
+}
@@ -0,0 +1,69 @@
+package gorm
+
+import (
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EnrollmentRepo struct {
+	db *gorm.DB
+}
+
+func NewEnrollmentRepo(db *gorm.DB) ports.Enrollment {
+	return &EnrollmentRepo{db: db}
+}
+
+// Save creates the enrollment of an owner or replaces the filters of the existing one
+func (r *EnrollmentRepo) Save(enrollment *models.Enrollment) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "owner"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"include_archived", "include_forks", "visibility", "language", "topic", "name_regex",
+		}),
+	}).Create(enrollment).Error
+	if err != nil {
+		return err
+	}
+	// the ID is not returned on conflict
+	return r.db.Where("owner = ?", enrollment.Owner).First(enrollment).Error
+}
+
+func (r *EnrollmentRepo) FindAll() ([]models.Enrollment, error) {
+	var enrollments []models.Enrollment
+	if err := r.db.Order("owner").Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	return enrollments, nil
+}
+
+func (r *EnrollmentRepo) FindByOwner(owner string) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	if err := r.db.Where("owner = ?", owner).First(&enrollment).Error; err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// Delete removes an enrollment. Its repositories stay, as if added on their own.
+func (r *EnrollmentRepo) Delete(owner string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var enrollment models.Enrollment
+		if err := tx.Where("owner = ?", owner).First(&enrollment).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Repository{}).Where("enrollment_id = ?", enrollment.ID).Update("enrollment_id", 0).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&enrollment).Error
+	})
+}
+
+func (r *EnrollmentRepo) MarkSynced(id uint, isOrg bool, syncedAt time.Time) error {
+	return r.db.Model(&models.Enrollment{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"is_org": isOrg, "synced_at": syncedAt}).Error
+}
@@ -0,0 +1,32 @@
+package gorm
+
+import (
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HTTPCacheRepo struct {
+	db *gorm.DB
+}
+
+func NewHTTPCacheRepo(db *gorm.DB) ports.HTTPCache {
+	return &HTTPCacheRepo{db: db}
+}
+
+func (c *HTTPCacheRepo) Get(url string) (*models.HTTPCacheEntry, error) {
+	var entry models.HTTPCacheEntry
+	if err := c.db.Where("url = ?", url).First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Save inserts the entry or replaces the validators of the existing one for the same URL
+func (c *HTTPCacheRepo) Save(entry *models.HTTPCacheEntry) error {
+	return c.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "url"}},
+		DoUpdates: clause.AssignmentColumns([]string{"repo_name", "etag", "last_modified", "body", "updated_at"}),
+	}).Create(entry).Error
+}
@@ -0,0 +1,260 @@
+package gorm
+
+import (
+	"strings"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdentityRepo struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepo(db *gorm.DB) ports.Identity {
+	return &IdentityRepo{db: db}
+}
+
+func (r *IdentityRepo) FindAll(page int, pageSize int) ([]*models.Identity, error) {
+	var identities []*models.Identity
+	if err := r.db.Preload("Aliases").
+		Order("id").
+		Scopes(paginate(page, pageSize)).
+		Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *IdentityRepo) FindRules() ([]models.AliasRule, error) {
+	var rules []models.AliasRule
+	if err := r.db.Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *IdentityRepo) CreateRule(rule *models.AliasRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *IdentityRepo) DeleteRule(id uint) error {
+	result := r.db.Delete(&models.AliasRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *IdentityRepo) ReplaceRules(source string, rules []models.AliasRule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source = ?", source).Delete(&models.AliasRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+}
+
+// Rebuild drops every identity and resolves all commits again, for when the alias rules changed
+func (r *IdentityRepo) Rebuild(batchSize int) (int, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.IdentityAlias{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("1 = 1").Delete(&models.Identity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.CommitParticipant{}).Where("1 = 1").Update("identity_id", 0).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Commit{}).Where("1 = 1").Update("author_identity_id", 0).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return relinkAll(r.db, batchSize)
+}
+
+// identityKey is an author as recorded on a commit
+type identityKey struct {
+	Name     string
+	Email    string
+	GitHubID int64
+}
+
+func newIdentityKey(name, email string, githubID int64) identityKey {
+	return identityKey{Name: name, Email: strings.ToLower(email), GitHubID: githubID}
+}
+
+// identityResolver maps commit authors to identities. Unknown authors are
+// attached to the identity sharing their GitHub user ID or canonical email, or
+// get a new one; an author linking two identities merges them.
+type identityResolver struct {
+	db    *gorm.DB
+	rules []models.AliasRule
+	known map[identityKey]uint
+}
+
+func newIdentityResolver(db *gorm.DB) (*identityResolver, error) {
+	var rules []models.AliasRule
+	if err := db.Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return &identityResolver{db: db, rules: rules, known: make(map[identityKey]uint)}, nil
+}
+
+func (r *identityResolver) lookup(key identityKey) (uint, bool, error) {
+	if id, ok := r.known[key]; ok {
+		return id, true, nil
+	}
+	var aliases []models.IdentityAlias
+	err := r.db.Where("name = ? AND email = ? AND github_id = ?", key.Name, key.Email, key.GitHubID).
+		Limit(1).Find(&aliases).Error
+	if err != nil || len(aliases) == 0 {
+		return 0, false, err
+	}
+	r.known[key] = aliases[0].IdentityID
+	return aliases[0].IdentityID, true, nil
+}
+
+func (r *identityResolver) resolve(key identityKey, login string) (uint, error) {
+	if id, ok, err := r.lookup(key); ok || err != nil {
+		return id, err
+	}
+
+	name, email := utils.ApplyAliasRules(r.rules, key.Name, key.Email)
+	var candidates []uint
+	if key.GitHubID != 0 {
+		if err := r.collect(&candidates, "github_id = ?", key.GitHubID); err != nil {
+			return 0, err
+		}
+	}
+	if email != "" {
+		if err := r.collect(&candidates, "email = ?", email); err != nil {
+			return 0, err
+		}
+	}
+
+	var identity models.Identity
+	created := len(candidates) == 0
+	if created {
+		identity = models.Identity{Name: name, Email: email, Login: login, GitHubID: key.GitHubID}
+		if err := r.db.Create(&identity).Error; err != nil {
+			return 0, err
+		}
+	} else {
+		if err := r.db.First(&identity, candidates[0]).Error; err != nil {
+			return 0, err
+		}
+		if err := r.merge(&identity, candidates[1:]); err != nil {
+			return 0, err
+		}
+		updates := map[string]interface{}{}
+		if name != key.Name {
+			// an alias rule names this person
+			updates["name"] = name
+		}
+		if identity.GitHubID == 0 && key.GitHubID != 0 {
+			updates["github_id"] = key.GitHubID
+			updates["login"] = login
+		}
+		if len(updates) > 0 {
+			if err := r.db.Model(&identity).Updates(updates).Error; err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	alias := models.IdentityAlias{IdentityID: identity.ID, Name: key.Name, Email: key.Email, GitHubID: key.GitHubID}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&alias)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// a concurrent ingest recorded this author first
+		if created {
+			if err := r.db.Delete(&identity).Error; err != nil {
+				return 0, err
+			}
+		}
+		id, _, err := r.lookup(key)
+		return id, err
+	}
+	r.known[key] = identity.ID
+	return identity.ID, nil
+}
+
+// collect appends the identities matching cond, directly or through an alias, keeping the lowest ID first
+func (r *identityResolver) collect(ids *[]uint, cond string, value interface{}) error {
+	var direct, viaAlias []uint
+	if err := r.db.Model(&models.Identity{}).Where(cond, value).Pluck("id", &direct).Error; err != nil {
+		return err
+	}
+	if err := r.db.Model(&models.IdentityAlias{}).Where(cond, value).Distinct().Pluck("identity_id", &viaAlias).Error; err != nil {
+		return err
+	}
+	for _, id := range append(direct, viaAlias...) {
+		if !containsID(*ids, id) {
+			*ids = append(*ids, id)
+		}
+	}
+	for i := 1; i < len(*ids); i++ {
+		if (*ids)[i] < (*ids)[0] {
+			(*ids)[0], (*ids)[i] = (*ids)[i], (*ids)[0]
+		}
+	}
+	return nil
+}
+
+// merge moves everything attributed to the others onto identity and deletes them
+func (r *identityResolver) merge(identity *models.Identity, others []uint) error {
+	if len(others) == 0 {
+		return nil
+	}
+	if identity.GitHubID == 0 {
+		var withGitHub models.Identity
+		err := r.db.Where("id IN ? AND github_id <> 0", others).Limit(1).Find(&withGitHub).Error
+		if err != nil {
+			return err
+		}
+		if withGitHub.ID != 0 {
+			identity.GitHubID, identity.Login = withGitHub.GitHubID, withGitHub.Login
+			if err := r.db.Model(identity).Updates(map[string]interface{}{"github_id": identity.GitHubID, "login": identity.Login}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if err := r.db.Model(&models.IdentityAlias{}).Where("identity_id IN ?", others).Update("identity_id", identity.ID).Error; err != nil {
+		return err
+	}
+	if err := r.db.Model(&models.Commit{}).Where("author_identity_id IN ?", others).Update("author_identity_id", identity.ID).Error; err != nil {
+		return err
+	}
+	if err := r.db.Model(&models.CommitParticipant{}).Where("identity_id IN ?", others).Update("identity_id", identity.ID).Error; err != nil {
+		return err
+	}
+	if err := r.db.Delete(&models.Identity{}, others).Error; err != nil {
+		return err
+	}
+	// cached aliases may point at a deleted identity
+	r.known = make(map[identityKey]uint)
+	return nil
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package gorm
+
+import (
+	"encoding/json"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IssueRepo struct {
+	db *gorm.DB
+}
+
+func NewIssueRepo(db *gorm.DB) ports.Issue {
+	return &IssueRepo{db: db}
+}
+
+// UpsertIssues inserts issues, refreshing the stored copy of any already present
+func (r *IssueRepo) UpsertIssues(issues []models.Issue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "repo_id"}, {Name: "number"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"title", "state", "state_reason", "author_login", "author_id", "assignees", "labels",
+			"milestone", "created_at", "updated_at", "closed_at", "url",
+		}),
+	}).Create(&issues).Error
+}
+
+func (r *IssueRepo) FindByNumber(repoID uint, number int) (*models.Issue, error) {
+	var issue models.Issue
+	if err := r.db.Where("repo_id = ? AND number = ?", repoID, number).First(&issue).Error; err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+func (r *IssueRepo) FindByRepoId(repoID uint, filter types.IssueFilter, page int, pageSize int) ([]*models.Issue, error) {
+	var issues []*models.Issue
+	query := r.db.Where("repo_id = ?", repoID)
+	if filter.State != "" {
+		query = query.Where("state = ?", filter.State)
+	}
+	if filter.Author != "" {
+		query = query.Where("author_login = ?", filter.Author)
+	}
+	if filter.Milestone != "" {
+		query = query.Where("milestone = ?", filter.Milestone)
+	}
+	// labels and assignees are stored as JSON arrays of strings
+	if filter.Label != "" {
+		quoted, _ := json.Marshal(filter.Label)
+		query = query.Where("labels LIKE ?", "%"+string(quoted)+"%")
+	}
+	if filter.Assignee != "" {
+		quoted, _ := json.Marshal(filter.Assignee)
+		query = query.Where("assignees LIKE ?", "%"+string(quoted)+"%")
+	}
+	if err := query.
+		Order("number DESC").
+		Scopes(paginate(page, pageSize)).
+		Find(&issues).Error; err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// FindReferencingCommits returns the stored commits whose message mentions an issue, oldest first
+func (r *IssueRepo) FindReferencingCommits(repoID uint, number int) ([]types.ReferencingCommit, error) {
+	var refs []models.CommitReference
+	if err := r.db.Where("repo_id = ? AND number = ?", repoID, number).Find(&refs).Error; err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	closes := make(map[uint]bool, len(refs))
+	ids := make([]uint, 0, len(refs))
+	for _, ref := range refs {
+		closes[ref.CommitID] = ref.Closes
+		ids = append(ids, ref.CommitID)
+	}
+	var commits []*models.Commit
+	if err := r.db.Where("id IN ?", ids).Order("date").Find(&commits).Error; err != nil {
+		return nil, err
+	}
+	result := make([]types.ReferencingCommit, 0, len(commits))
+	for _, cmt := range commits {
+		result = append(result, types.ReferencingCommit{Commit: cmt, Closes: closes[cmt.ID]})
+	}
+	return result, nil
+}
@@ -0,0 +1,39 @@
+package gorm
+
+import (
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"gorm.io/gorm"
+)
+
+// Migrate creates missing tables and adds new columns to existing ones. Columns
+// added after the first release are either NOT NULL with a default or nullable,
+// like the committer date and parents of commits, which rows stored by older
+// versions read back as zero values until they are fetched again.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.Repository{},
+		&models.Commit{},
+		&models.HTTPCacheEntry{},
+		&models.CommitFile{},
+		&models.CommitParticipant{},
+		&models.Identity{},
+		&models.IdentityAlias{},
+		&models.AliasRule{},
+		&models.RepositoryBranch{},
+		&models.CommitBranch{},
+		&models.HistoryRewrite{},
+		&models.PullRequest{},
+		&models.PullRequestReview{},
+		&models.ReviewComment{},
+		&models.Issue{},
+		&models.CommitReference{},
+		&models.Tag{},
+		&models.Release{},
+		&models.RepositorySnapshot{},
+		&models.ContributorWeek{},
+		&models.CommitActivityWeek{},
+		&models.WorkflowRun{},
+		&models.WorkflowJob{},
+		&models.Enrollment{},
+	)
+}
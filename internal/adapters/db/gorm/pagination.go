@@ -0,0 +1,11 @@
+package gorm
+
+import "gorm.io/gorm"
+
+// paginate selects the rows of a page followed by the first row of the next
+// page, if any, so callers can tell whether there is one
+func paginate(page int, pageSize int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Limit(pageSize + 1).Offset((page - 1) * pageSize)
+	}
+}
@@ -0,0 +1,213 @@
+package gorm
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PullRequestRepo struct {
+	db *gorm.DB
+}
+
+func NewPullRequestRepo(db *gorm.DB) ports.PullRequest {
+	return &PullRequestRepo{db: db}
+}
+
+// UpsertPullRequests inserts pull requests, refreshing the stored copy of any
+// already present, and links the merged ones to their stored merge commits
+func (r *PullRequestRepo) UpsertPullRequests(prs []models.PullRequest) error {
+	if len(prs) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "repo_id"}, {Name: "number"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"title", "state", "draft", "author_login", "author_id", "base_branch", "head_branch",
+				"merge_commit_sha", "labels", "additions", "deletions", "created_at", "updated_at",
+				"merged_at", "closed_at", "url",
+			}),
+		}).Create(&prs).Error
+		if err != nil {
+			return err
+		}
+		numbers := make([]int, 0, len(prs))
+		for _, pr := range prs {
+			numbers = append(numbers, pr.Number)
+		}
+		return linkMergeCommits(tx, tx.Where("repo_id = ? AND number IN ?", prs[0].RepoID, numbers))
+	})
+}
+
+// linkMergeCommits points the pull requests selected by scope at their stored merge commits
+func linkMergeCommits(db *gorm.DB, scope *gorm.DB) error {
+	mergeCommit := db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Commit{}).
+		Select("id").
+		Where("commits.hash = pull_requests.merge_commit_sha")
+	return db.Model(&models.PullRequest{}).
+		Where(scope).
+		Where("merge_commit_sha <> ''").
+		Update("merge_commit_id", gorm.Expr("COALESCE((?), 0)", mergeCommit)).Error
+}
+
+func (r *PullRequestRepo) FindByNumber(repoID uint, number int) (*models.PullRequest, error) {
+	var pr models.PullRequest
+	if err := r.db.Where("repo_id = ? AND number = ?", repoID, number).First(&pr).Error; err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func (r *PullRequestRepo) FindByRepoId(repoID uint, filter types.PullRequestFilter, page int, pageSize int) ([]*models.PullRequest, error) {
+	var prs []*models.PullRequest
+	query := r.db.Where("repo_id = ?", repoID)
+	if filter.State != "" {
+		query = query.Where("state = ?", filter.State)
+	}
+	if filter.Merged != nil {
+		if *filter.Merged {
+			query = query.Where("merged_at IS NOT NULL")
+		} else {
+			query = query.Where("merged_at IS NULL")
+		}
+	}
+	if filter.Author != "" {
+		query = query.Where("author_login = ?", filter.Author)
+	}
+	if filter.Base != "" {
+		query = query.Where("base_branch = ?", filter.Base)
+	}
+	if filter.Label != "" {
+		// labels are stored as a JSON array of strings
+		quoted, _ := json.Marshal(filter.Label)
+		query = query.Where("labels LIKE ?", "%"+string(quoted)+"%")
+	}
+	if err := query.
+		Order("number DESC").
+		Scopes(paginate(page, pageSize)).
+		Find(&prs).Error; err != nil {
+		return nil, err
+	}
+	return prs, nil
+}
+
+func (r *PullRequestRepo) SaveReviews(repoID uint, number int, reviews []models.PullRequestReview, comments []models.ReviewComment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo_id = ? AND number = ?", repoID, number).Delete(&models.PullRequestReview{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("repo_id = ? AND number = ?", repoID, number).Delete(&models.ReviewComment{}).Error; err != nil {
+			return err
+		}
+		if len(reviews) > 0 {
+			if err := tx.Create(&reviews).Error; err != nil {
+				return err
+			}
+		}
+		if len(comments) > 0 {
+			return tx.Create(&comments).Error
+		}
+		return nil
+	})
+}
+
+func (r *PullRequestRepo) FindReviewTimings(repoID uint, window types.DateWindow) ([]types.PullRequestTiming, error) {
+	var prs []models.PullRequest
+	err := r.db.Select("number", "author_login", "created_at", "merged_at").
+		Where("repo_id = ? AND created_at >= ? AND created_at < ?", repoID, window.Since, window.Until).
+		Order("number").
+		Find(&prs).Error
+	if err != nil || len(prs) == 0 {
+		return nil, err
+	}
+	numbers := make([]int, 0, len(prs))
+	for _, pr := range prs {
+		numbers = append(numbers, pr.Number)
+	}
+	var reviews []models.PullRequestReview
+	err = r.db.Where("repo_id = ? AND number IN ? AND state <> ? AND submitted_at IS NOT NULL", repoID, numbers, models.ReviewPending).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, err
+	}
+
+	timings := make([]types.PullRequestTiming, len(prs))
+	index := make(map[int]int, len(prs))
+	for i, pr := range prs {
+		timings[i] = types.PullRequestTiming{Number: pr.Number, CreatedAt: pr.CreatedAt, MergedAt: pr.MergedAt}
+		index[pr.Number] = i
+	}
+	for _, review := range reviews {
+		i := index[review.Number]
+		if review.ReviewerLogin == prs[i].AuthorLogin {
+			// authors answering on their own pull request do not count as a review
+			continue
+		}
+		timing := &timings[i]
+		if timing.FirstReviewAt == nil || review.SubmittedAt.Before(*timing.FirstReviewAt) {
+			timing.FirstReviewAt = review.SubmittedAt
+		}
+		if review.State == models.ReviewApproved && (timing.ApprovedAt == nil || review.SubmittedAt.Before(*timing.ApprovedAt)) {
+			timing.ApprovedAt = review.SubmittedAt
+		}
+	}
+	return timings, nil
+}
+
+func (r *PullRequestRepo) GetReviewLoad(repoID uint, window types.DateWindow) ([]types.ReviewerLoad, error) {
+	var load []types.ReviewerLoad
+	err := r.db.Model(&models.PullRequestReview{}).
+		Select(`reviewer_login AS reviewer, COUNT(DISTINCT number) AS pull_requests, COUNT(*) AS reviews,
+			SUM(CASE WHEN state = ? THEN 1 ELSE 0 END) AS approvals,
+			SUM(CASE WHEN state = ? THEN 1 ELSE 0 END) AS changes_requested`, models.ReviewApproved, models.ReviewChangesRequested).
+		Where("repo_id = ? AND state <> ? AND submitted_at >= ? AND submitted_at < ?", repoID, models.ReviewPending, window.Since, window.Until).
+		Group("reviewer_login").
+		Scan(&load).Error
+	if err != nil {
+		return nil, err
+	}
+	var comments []struct {
+		Reviewer string
+		Comments int
+	}
+	err = r.db.Model(&models.ReviewComment{}).
+		Select("author_login AS reviewer, COUNT(*) AS comments").
+		Where("repo_id = ? AND created_at >= ? AND created_at < ?", repoID, window.Since, window.Until).
+		Group("author_login").
+		Scan(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(load))
+	for i, l := range load {
+		index[l.Reviewer] = i
+	}
+	for _, c := range comments {
+		i, ok := index[c.Reviewer]
+		if !ok {
+			// commented on diffs without submitting a review in the window
+			load = append(load, types.ReviewerLoad{Reviewer: c.Reviewer})
+			i = len(load) - 1
+			index[c.Reviewer] = i
+		}
+		load[i].Comments = c.Comments
+	}
+	sort.SliceStable(load, func(a, b int) bool {
+		if load[a].Reviews != load[b].Reviews {
+			return load[a].Reviews > load[b].Reviews
+		}
+		if load[a].Comments != load[b].Comments {
+			return load[a].Comments > load[b].Comments
+		}
+		return load[a].Reviewer < load[b].Reviewer
+	})
+	return load, nil
+}
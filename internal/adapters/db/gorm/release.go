@@ -0,0 +1,105 @@
+package gorm
+
+import (
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"gorm.io/gorm"
+)
+
+type ReleaseRepo struct {
+	db *gorm.DB
+}
+
+func NewReleaseRepo(db *gorm.DB) ports.Release {
+	return &ReleaseRepo{db: db}
+}
+
+// SaveTags replaces the stored tags of a repository, as tags can be deleted
+// and moved, and points its releases at the commits of their tags
+func (r *ReleaseRepo) SaveTags(repoID uint, tags []models.Tag) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo_id = ?", repoID).Delete(&models.Tag{}).Error; err != nil {
+			return err
+		}
+		if len(tags) > 0 {
+			if err := tx.Create(&tags).Error; err != nil {
+				return err
+			}
+		}
+		return linkReleaseTargets(tx, repoID)
+	})
+}
+
+// SaveReleases replaces the stored releases of a repository, as drafts come and go
+func (r *ReleaseRepo) SaveReleases(repoID uint, releases []models.Release) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo_id = ?", repoID).Delete(&models.Release{}).Error; err != nil {
+			return err
+		}
+		if len(releases) > 0 {
+			if err := tx.Create(&releases).Error; err != nil {
+				return err
+			}
+		}
+		return linkReleaseTargets(tx, repoID)
+	})
+}
+
+// linkReleaseTargets sets the target SHA of the releases of a repository to the commit of their tag
+func linkReleaseTargets(db *gorm.DB, repoID uint) error {
+	tagSHA := db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Tag{}).
+		Select("sha").
+		Where("tags.repo_id = releases.repo_id AND tags.name = releases.tag_name")
+	return db.Model(&models.Release{}).
+		Where("repo_id = ?", repoID).
+		Update("target_sha", gorm.Expr("COALESCE((?), '')", tagSHA)).Error
+}
+
+func (r *ReleaseRepo) FindReleases(repoID uint, page int, pageSize int) ([]*models.Release, error) {
+	var releases []*models.Release
+	// drafts have no publication date and come last
+	if err := r.db.Where("repo_id = ?", repoID).
+		Order("published_at DESC, created_at DESC").
+		Scopes(paginate(page, pageSize)).
+		Find(&releases).Error; err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+func (r *ReleaseRepo) FindByTag(repoID uint, tag string) (*models.Release, error) {
+	var release models.Release
+	if err := r.db.Where("repo_id = ? AND tag_name = ?", repoID, tag).First(&release).Error; err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// FindLatest returns the most recently published release that is neither a draft nor a prerelease
+func (r *ReleaseRepo) FindLatest(repoID uint) (*models.Release, error) {
+	var release models.Release
+	if err := r.db.Where("repo_id = ? AND draft = ? AND prerelease = ? AND published_at IS NOT NULL", repoID, false, false).
+		Order("published_at DESC").
+		First(&release).Error; err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// FindPrevious returns the release published last before release. Prereleases
+// are skipped unless release is one itself.
+func (r *ReleaseRepo) FindPrevious(release *models.Release) (*models.Release, error) {
+	if release.PublishedAt == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	query := r.db.Where("repo_id = ? AND draft = ? AND published_at < ?", release.RepoID, false, *release.PublishedAt)
+	if !release.Prerelease {
+		query = query.Where("prerelease = ?", false)
+	}
+	var previous models.Release
+	if err := query.Order("published_at DESC").First(&previous).Error; err != nil {
+		return nil, err
+	}
+	return &previous, nil
+}
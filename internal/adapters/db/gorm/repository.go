@@ -1,7 +1,10 @@
 package gorm
 
 import (
+	"time"
+
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
 	"gorm.io/gorm"
 )
@@ -43,3 +46,167 @@ func (r *Repository) UpdateLastCommitSHA(id uint, sha string) error {
 	}
 	return nil
 }
+
+func (r *Repository) UpdateInstallationID(id uint, installationID int64) error {
+	return r.db.Model(&models.Repository{}).
+		Where("id = ?", id).
+		Update("installation_id", installationID).Error
+}
+
+func (r *Repository) UpdateEnrichCommits(id uint, enabled bool) error {
+	return r.db.Model(&models.Repository{}).
+		Where("id = ?", id).
+		Update("enrich_commits", enabled).Error
+}
+
+func (r *Repository) UpdateDefaultBranch(id uint, branch string) error {
+	return r.db.Model(&models.Repository{}).
+		Where("id = ?", id).
+		Update("default_branch", branch).Error
+}
+
+func (r *Repository) UpdateBranchPatterns(id uint, patterns []string) error {
+	return r.db.Model(&models.Repository{ID: id}).
+		Select("branch_patterns").
+		Updates(&models.Repository{BranchPatterns: patterns}).Error
+}
+
+func (r *Repository) FindBranches(id uint) ([]models.RepositoryBranch, error) {
+	var branches []models.RepositoryBranch
+	if err := r.db.Where("repo_id = ?", id).Order("name").Find(&branches).Error; err != nil {
+		return nil, err
+	}
+	return branches, nil
+}
+
+func (r *Repository) UpdatePullsSyncedAt(id uint, syncedAt time.Time) error {
+	return r.db.Model(&models.Repository{}).
+		Where("id = ?", id).
+		Update("pulls_synced_at", syncedAt).Error
+}
+
+func (r *Repository) UpdateIssuesSyncedAt(id uint, syncedAt time.Time) error {
+	return r.db.Model(&models.Repository{}).
+		Where("id = ?", id).
+		Update("issues_synced_at", syncedAt).Error
+}
+
+func (r *Repository) UpdateRunsSyncedAt(id uint, syncedAt time.Time) error {
+	return r.db.Model(&models.Repository{}).
+		Where("id = ?", id).
+		Update("runs_synced_at", syncedAt).Error
+}
+
+// RecordSnapshot refreshes the stored metadata of a repository from meta and
+// appends it to the repository's snapshots in the same transaction
+func (r *Repository) RecordSnapshot(id uint, meta *models.Repository, takenAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Repository{ID: id}).
+			Select("description", "language", "forks_count", "stars_count", "open_issues_count", "watchers_count",
+				"subscribers_count", "size", "topics", "archived", "fetched_at").
+			Updates(&models.Repository{
+				Description:      meta.Description,
+				Language:         meta.Language,
+				ForksCount:       meta.ForksCount,
+				StarsCount:       meta.StarsCount,
+				OpenIssuesCount:  meta.OpenIssuesCount,
+				WatchersCount:    meta.WatchersCount,
+				SubscribersCount: meta.SubscribersCount,
+				Size:             meta.Size,
+				Topics:           meta.Topics,
+				Archived:         meta.Archived,
+				FetchedAt:        takenAt,
+			}).Error
+		if err != nil {
+			return err
+		}
+		snapshot := models.NewRepositorySnapshot(id, meta, takenAt)
+		return tx.Create(&snapshot).Error
+	})
+}
+
+func (r *Repository) FindSnapshots(id uint, window types.DateWindow) ([]models.RepositorySnapshot, error) {
+	var snapshots []models.RepositorySnapshot
+	if err := r.db.Where("repo_id = ? AND taken_at >= ? AND taken_at < ?", id, window.Since, window.Until).
+		Order("taken_at").
+		Find(&snapshots).Error; err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+func (r *Repository) FindByEnrollment(enrollmentID uint) ([]*models.Repository, error) {
+	var repos []*models.Repository
+	if err := r.db.Where("enrollment_id = ?", enrollmentID).Order("full_name").Find(&repos).Error; err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+func (r *Repository) UpdateStatus(id uint, status string) error {
+	return r.db.Model(&models.Repository{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
+func (r *Repository) UpdateEnrollment(id uint, enrollmentID uint) error {
+	return r.db.Model(&models.Repository{}).
+		Where("id = ?", id).
+		Update("enrollment_id", enrollmentID).Error
+}
+
+// ResetCursors clears the head and backfill watermarks of the repository and of
+// every branch fetched. The commits stay; those fetched again are upserted.
+func (r *Repository) ResetCursors(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		cursors := map[string]interface{}{"head_sha": "", "head_date": nil, "last_commit_sha": "", "backfill_date": nil}
+		if err := tx.Model(&models.Repository{}).Where("id = ?", id).Updates(cursors).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RepositoryBranch{}).Where("repo_id = ?", id).Updates(cursors).Error
+	})
+}
+
+// Delete removes a repository and every row stored for it in one transaction.
+// Identities are shared between repositories and stay.
+func (r *Repository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var repo models.Repository
+		if err := tx.First(&repo, id).Error; err != nil {
+			return err
+		}
+		// branches of other repositories, such as forks, linked to its commits
+		ownCommits := tx.Model(&models.Commit{}).Select("id").Where("repo_id = ?", id)
+		if err := tx.Where("commit_id IN (?)", ownCommits).Delete(&models.CommitBranch{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{
+			&models.Commit{},
+			&models.CommitFile{},
+			&models.CommitParticipant{},
+			&models.CommitBranch{},
+			&models.CommitReference{},
+			&models.RepositoryBranch{},
+			&models.HistoryRewrite{},
+			&models.PullRequest{},
+			&models.PullRequestReview{},
+			&models.ReviewComment{},
+			&models.Issue{},
+			&models.Tag{},
+			&models.Release{},
+			&models.RepositorySnapshot{},
+			&models.ContributorWeek{},
+			&models.CommitActivityWeek{},
+			&models.WorkflowRun{},
+			&models.WorkflowJob{},
+		} {
+			if err := tx.Where("repo_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("repo_name = ?", repo.FullName).Delete(&models.HTTPCacheEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&repo).Error
+	})
+}
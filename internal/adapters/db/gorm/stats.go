@@ -0,0 +1,68 @@
+package gorm
+
+import (
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StatsRepo struct {
+	db *gorm.DB
+}
+
+func NewStatsRepo(db *gorm.DB) ports.Stats {
+	return &StatsRepo{db: db}
+}
+
+// SaveContributorStats replaces the contributor weeks of a repository; GitHub
+// returns the whole history every time and recomputes it after force pushes
+func (r *StatsRepo) SaveContributorStats(repoID uint, weeks []models.ContributorWeek) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo_id = ?", repoID).Delete(&models.ContributorWeek{}).Error; err != nil {
+			return err
+		}
+		if len(weeks) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&weeks, 100).Error
+	})
+}
+
+// SaveCommitActivity upserts the commit activity weeks of a repository. GitHub
+// only returns the last year, so older weeks are kept.
+func (r *StatsRepo) SaveCommitActivity(repoID uint, weeks []models.CommitActivityWeek) error {
+	if len(weeks) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repo_id"}, {Name: "week"}},
+		DoUpdates: clause.AssignmentColumns([]string{"total", "days"}),
+	}).Create(&weeks).Error
+}
+
+func (r *StatsRepo) FindCommitActivity(repoID uint, window types.DateWindow) ([]models.CommitActivityWeek, error) {
+	var weeks []models.CommitActivityWeek
+	if err := r.db.Where("repo_id = ? AND week >= ? AND week < ?", repoID, window.Since, window.Until).
+		Order("week").
+		Find(&weeks).Error; err != nil {
+		return nil, err
+	}
+	return weeks, nil
+}
+
+// FindContributorActivity sums the contributor weeks starting in window by contributor, most commits first
+func (r *StatsRepo) FindContributorActivity(repoID uint, window types.DateWindow) ([]types.ContributorActivity, error) {
+	var activity []types.ContributorActivity
+	err := r.db.Model(&models.ContributorWeek{}).
+		Select("login, author_id, SUM(commits) AS commits, SUM(additions) AS additions, SUM(deletions) AS deletions, COUNT(*) AS active_weeks").
+		Where("repo_id = ? AND week >= ? AND week < ?", repoID, window.Since, window.Until).
+		Group("login, author_id").
+		Order("commits DESC, login").
+		Scan(&activity).Error
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
@@ -0,0 +1,91 @@
+package gorm
+
+import (
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WorkflowRepo struct {
+	db *gorm.DB
+}
+
+func NewWorkflowRepo(db *gorm.DB) ports.Workflow {
+	return &WorkflowRepo{db: db}
+}
+
+// UpsertRuns inserts workflow runs, refreshing the stored copy of any already
+// present, and links them to their stored head commits
+func (r *WorkflowRepo) UpsertRuns(runs []models.WorkflowRun) error {
+	if len(runs) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Omit("Jobs").Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "github_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"workflow_name", "run_attempt", "status", "conclusion", "started_at", "updated_at", "url",
+			}),
+		}).Create(&runs).Error
+		if err != nil {
+			return err
+		}
+		ids := make([]int64, 0, len(runs))
+		for _, run := range runs {
+			ids = append(ids, run.GitHubID)
+		}
+		return linkRunCommits(tx, tx.Where("github_id IN ?", ids))
+	})
+}
+
+// linkRunCommits points the workflow runs selected by scope at their stored head commits
+func linkRunCommits(db *gorm.DB, scope *gorm.DB) error {
+	headCommit := db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Commit{}).
+		Select("id").
+		Where("commits.hash = workflow_runs.head_sha")
+	return db.Model(&models.WorkflowRun{}).
+		Where(scope).
+		Update("commit_id", gorm.Expr("COALESCE((?), 0)", headCommit)).Error
+}
+
+// SaveJobs replaces the stored jobs of a workflow run
+func (r *WorkflowRepo) SaveJobs(runID int64, jobs []models.WorkflowJob) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("run_id = ?", runID).Delete(&models.WorkflowJob{}).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		return tx.Create(&jobs).Error
+	})
+}
+
+// FindRunsByCommit returns the workflow runs of a commit with their jobs, oldest first
+func (r *WorkflowRepo) FindRunsByCommit(repoID uint, sha string) ([]models.WorkflowRun, error) {
+	var runs []models.WorkflowRun
+	if err := r.db.Preload("Jobs", func(db *gorm.DB) *gorm.DB {
+		return db.Order("run_attempt, started_at, github_id")
+	}).Where("repo_id = ? AND head_sha = ?", repoID, sha).
+		Order("created_at").
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// FindRuns returns the workflow runs created in window, of one workflow when workflow is set, oldest first
+func (r *WorkflowRepo) FindRuns(repoID uint, window types.DateWindow, workflow string) ([]models.WorkflowRun, error) {
+	query := r.db.Where("repo_id = ? AND created_at >= ? AND created_at < ?", repoID, window.Since, window.Until)
+	if workflow != "" {
+		query = query.Where("workflow_name = ?", workflow)
+	}
+	var runs []models.WorkflowRun
+	if err := query.Order("created_at").Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
 )
 
@@ -16,8 +22,7 @@ func (h *AppHandler) FetchRepository(gc *gin.Context) {
 
 	_, err := h.InitNewRepository(repoName)
 	if err != nil {
-		utils.InfoResponse(gc, err.
-			Error(), nil, http.StatusInternalServerError)
+		utils.InfoResponse(gc, err.Error(), nil, githubErrorStatus(gc, err))
 		return
 	}
 
@@ -25,13 +30,29 @@ func (h *AppHandler) FetchRepository(gc *gin.Context) {
 	utils.InfoResponse(gc, "success", nil, http.StatusOK)
 }
 
-func (h *AppHandler) ListRepositories(gc *gin.Context) {
-	repos, err := h.RepositoryRepo.FindAll()
+// GetRepository returns a stored repository with the head and backfill
+// watermarks of its default branch and of every other branch fetched
+func (h *AppHandler) GetRepository(gc *gin.Context) {
+	repoName := gc.Query("repo")
+	if repoName == "" {
+		utils.InfoResponse(gc, "Missing repo param", nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(repoName)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	h.respondRepository(gc, repo)
+}
+
+func (h *AppHandler) respondRepository(gc *gin.Context, repo *models.Repository) {
+	branches, err := h.RepositoryRepo.FindBranches(repo.ID)
 	if err != nil {
 		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
 		return
 	}
-	utils.InfoResponse(gc, "success", repos, http.StatusOK)
+	utils.InfoResponse(gc, "success", types.RepositoryResponse{Repository: repo, Branches: branches}, http.StatusOK)
 }
 
 func (h *AppHandler) ListCommits(gc *gin.Context) {
@@ -52,3 +73,36 @@ func (h *AppHandler) UpdateCommit(gc *gin.Context) {
 	}
 	utils.InfoResponse(gc, "success", nil, http.StatusOK)
 }
+
+func (h *AppHandler) GetRateLimit(gc *gin.Context) {
+	utils.InfoResponse(gc, "success", h.GithubService.RateLimitStatus(), http.StatusOK)
+}
+
+func (h *AppHandler) GetTokenStatus(gc *gin.Context) {
+	utils.InfoResponse(gc, "success", h.GithubService.TokenStatus(), http.StatusOK)
+}
+
+func (h *AppHandler) GetRetryStats(gc *gin.Context) {
+	utils.InfoResponse(gc, "success", h.GithubService.RetryStats(), http.StatusOK)
+}
+
+// githubErrorStatus maps GitHub adapter errors to the status returned to our own
+// clients, setting Retry-After for rate limits.
+func githubErrorStatus(gc *gin.Context, err error) int {
+	var rateLimitErr ports.RateLimitedError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		gc.Header("Retry-After", fmt.Sprint(int(math.Ceil(rateLimitErr.RetryAfter().Seconds()))))
+		return http.StatusTooManyRequests
+	case errors.Is(err, ports.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ports.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ports.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ports.ErrUpstream), errors.Is(err, ports.ErrDecode):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+const (
+	defaultBackfillWorkers = 4
+	defaultBackfillWindow  = 30 * 24 * time.Hour
+)
+
+// BackfillOptions controls the parallel import of a newly added repository.
+// Workers of 1 or less keeps the sequential import.
+type BackfillOptions struct {
+	Workers int
+	Window  time.Duration
+}
+
+func (o BackfillOptions) withDefaults() BackfillOptions {
+	if o.Workers == 0 {
+		o.Workers = defaultBackfillWorkers
+	}
+	if o.Window <= 0 {
+		o.Window = defaultBackfillWindow
+	}
+	return o
+}
+
+// backfillTracker keeps the progress of every backfill run, keyed by repository
+type backfillTracker struct {
+	mu   sync.Mutex
+	runs map[string]*types.BackfillProgress
+}
+
+func (t *backfillTracker) start(repoName string, windows []types.DateWindow) {
+	progress := &types.BackfillProgress{
+		Repo:      repoName,
+		Status:    types.BackfillRunning,
+		StartedAt: time.Now(),
+		Windows:   make([]types.BackfillWindowProgress, len(windows)),
+	}
+	for i, w := range windows {
+		progress.Windows[i] = types.BackfillWindowProgress{DateWindow: w, Status: types.BackfillPending}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.runs == nil {
+		t.runs = make(map[string]*types.BackfillProgress)
+	}
+	t.runs[repoName] = progress
+}
+
+func (t *backfillTracker) update(repoName string, fn func(p *types.BackfillProgress)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p, ok := t.runs[repoName]; ok {
+		fn(p)
+	}
+}
+
+// snapshot copies the progress of repoName, or of every run when it is empty
+func (t *backfillTracker) snapshot(repoName string) []types.BackfillProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var runs []types.BackfillProgress
+	for name, p := range t.runs {
+		if repoName != "" && name != repoName {
+			continue
+		}
+		run := *p
+		run.Windows = append([]types.BackfillWindowProgress{}, p.Windows...)
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// canBackfill reports whether config describes the first import of a repository
+// over a date range that can be split up
+func (h *AppHandler) canBackfill(config models.CommitConfig) bool {
+	return h.Backfill.withDefaults().Workers > 1 &&
+		config.Sha == "" && config.StartDate != "" && config.EndDate != ""
+}
+
+// BackfillRepository imports a new repository window by window, fetching the
+// windows concurrently with a bounded number of workers. All workers share the
+// GitHub client and so its rate limiting. Each window drops the commits it has
+// already seen; a commit on the border of two windows is stored by both and the
+// upsert on its hash keeps a single row. The watermarks follow the windows finished without a gap
+// from the newest one: the head is the newest commit of that run of windows and
+// the backfill watermark its oldest, so after a crash or a failed window the
+// next sync continues from the oldest commit of that run instead of starting
+// over.
+func (h *AppHandler) BackfillRepository(repo *models.Repository, windows []types.DateWindow) error {
+	opts := h.Backfill.withDefaults()
+	h.backfills.start(repo.FullName, windows)
+	h.logger.Sugar().Infof("Backfilling %s over %d windows with %d workers", repo.FullName, len(windows), opts.Workers)
+
+	var (
+		mu     sync.Mutex
+		stored int
+		// seen holds the hashes of the windows being fetched, dropped when they finish
+		seen   = make([]map[string]struct{}, len(windows))
+		bounds = make([]windowBounds, len(windows))
+		// windows[covered:] are finished and reflected in the watermarks
+		covered = len(windows)
+		oldest  models.Commit
+		newest  models.Commit
+	)
+	// dedupe drops commits already stored by the same window and tracks the oldest
+	// and newest ones stored by each window
+	dedupe := func(idx int, commits []models.Commit) []models.Commit {
+		mu.Lock()
+		defer mu.Unlock()
+		if seen[idx] == nil {
+			seen[idx] = make(map[string]struct{})
+		}
+		fresh := make([]models.Commit, 0, len(commits))
+		for _, cmt := range commits {
+			if _, ok := seen[idx][cmt.Hash]; ok {
+				continue
+			}
+			seen[idx][cmt.Hash] = struct{}{}
+			stored++
+			fresh = append(fresh, cmt)
+			bounds[idx].add(cmt)
+		}
+		return fresh
+	}
+	// finish records a window as stored and moves the watermarks over the windows
+	// finished without a gap from the newest one
+	finish := func(idx int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[idx] = nil
+		bounds[idx].done = true
+		moved := false
+		for covered > 0 && bounds[covered-1].done {
+			covered--
+			w := bounds[covered]
+			if w.oldest.Hash != "" && (oldest.Hash == "" || w.oldest.CommittedAt().Before(oldest.CommittedAt())) {
+				oldest, moved = w.oldest, true
+			}
+			if w.newest.Hash != "" && (newest.Hash == "" || w.newest.CommittedAt().After(newest.CommittedAt())) {
+				newest, moved = w.newest, true
+			}
+		}
+		if !moved {
+			return nil
+		}
+		if err := h.CommitRepo.AdvanceHead(repo.ID, "", newest); err != nil {
+			return err
+		}
+		return h.CommitRepo.UpsertPage(repo.ID, "", []models.Commit{oldest}, oldest.Hash)
+	}
+
+	jobs := make(chan int)
+	errs := make(chan error, len(windows))
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				err := h.backfillWindow(repo, windows[idx], idx, dedupe)
+				if err == nil {
+					err = finish(idx)
+				}
+				errs <- err
+			}
+		}()
+	}
+	for idx := range windows {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	var firstErr error
+	for err := range errs {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	h.backfills.update(repo.FullName, func(p *types.BackfillProgress) {
+		p.FinishedAt = time.Now()
+		p.Status = types.BackfillDone
+		if firstErr != nil {
+			p.Status = types.BackfillFailed
+		}
+	})
+	if firstErr != nil {
+		return fmt.Errorf("backfill of %s failed: %w", repo.FullName, firstErr)
+	}
+	h.logger.Sugar().Infof("Backfill of %s complete, %d commits", repo.FullName, stored)
+	return nil
+}
+
+// windowBounds are the oldest and newest commits stored by a backfill window
+type windowBounds struct {
+	done   bool
+	oldest models.Commit
+	newest models.Commit
+}
+
+func (b *windowBounds) add(cmt models.Commit) {
+	if b.oldest.Hash == "" || cmt.CommittedAt().Before(b.oldest.CommittedAt()) {
+		b.oldest = cmt
+	}
+	if b.newest.Hash == "" || cmt.CommittedAt().After(b.newest.CommittedAt()) {
+		b.newest = cmt
+	}
+}
+
+func (h *AppHandler) backfillWindow(repo *models.Repository, window types.DateWindow, idx int, dedupe func(int, []models.Commit) []models.Commit) error {
+	h.backfills.update(repo.FullName, func(p *types.BackfillProgress) {
+		p.Windows[idx].Status = types.BackfillRunning
+	})
+	windowConfig := models.CommitConfig{
+		StartDate: window.Since.UTC().Format(time.RFC3339),
+		EndDate:   window.Until.UTC().Format(time.RFC3339),
+	}
+	err := h.GithubService.FetchCommits(repo.FullName, repo.ID, windowConfig, func(commits []models.Commit, _ string) error {
+		fresh := dedupe(idx, commits)
+		// pages of concurrent windows arrive out of order, so they are linked to the
+		// default branch without moving its backfill watermark
+		if err := h.CommitRepo.UpsertPage(repo.ID, "", fresh, ""); err != nil {
+			return err
+		}
+		h.backfills.update(repo.FullName, func(p *types.BackfillProgress) {
+			p.Windows[idx].Pages++
+			p.Windows[idx].Commits += len(fresh)
+			p.Commits += len(fresh)
+			p.Duplicates += len(commits) - len(fresh)
+		})
+		return nil
+	})
+
+	h.backfills.update(repo.FullName, func(p *types.BackfillProgress) {
+		p.Windows[idx].Status = types.BackfillDone
+		if err != nil {
+			p.Windows[idx].Status = types.BackfillFailed
+			p.Windows[idx].Error = err.Error()
+		}
+	})
+	if err != nil {
+		h.logger.Sugar().Errorf("Backfill window %s..%s of %s failed: %v", windowConfig.StartDate, windowConfig.EndDate, repo.FullName, err)
+		return err
+	}
+	h.logger.Sugar().Infof("Backfill window %s..%s of %s done", windowConfig.StartDate, windowConfig.EndDate, repo.FullName)
+	return nil
+}
+
+func (h *AppHandler) GetBackfillProgress(gc *gin.Context) {
+	utils.InfoResponse(gc, "success", h.backfills.snapshot(gc.Query("repo")), http.StatusOK)
+}
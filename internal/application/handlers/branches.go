@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// matchBranches returns the branches matched by any of patterns, leaving out
+// the default branch which is fetched on its own
+func matchBranches(branches []string, patterns []string, defaultBranch string) []string {
+	var matched []string
+	for _, branch := range branches {
+		if branch == defaultBranch {
+			continue
+		}
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, branch); ok {
+				matched = append(matched, branch)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// syncBranches fetches every non-default branch selected by the repository's
+// branch patterns, each from its own watermarks. A failing branch is logged
+// and does not stop the others.
+func (h *AppHandler) syncBranches(repoName string, config models.CommitConfig) {
+	repo, err := h.RepositoryRepo.FindByName(repoName)
+	if err != nil || len(repo.BranchPatterns) == 0 {
+		return
+	}
+	names, err := h.GithubService.ListBranches(repo.FullName)
+	if err != nil {
+		h.logger.Sugar().Error("ListBranches error: ", err)
+		return
+	}
+	states := make(map[string]branchSync)
+	if stored, err := h.RepositoryRepo.FindBranches(repo.ID); err == nil {
+		for _, branch := range stored {
+			states[branch.Name] = branchSync{HeadSHA: branch.HeadSHA, HeadDate: branch.HeadDate, BackfillSHA: branch.LastCommitSHA}
+		}
+	}
+	for _, branch := range matchBranches(names, repo.BranchPatterns, repo.DefaultBranch) {
+		state := states[branch]
+		state.Name, state.Key = branch, branch
+		h.logger.Sugar().Infof("Fetching branch %s of %s", branch, repo.FullName)
+		if err := h.syncBranch(repo, state, config); err != nil {
+			h.logger.Sugar().Errorf("Fetching branch %s of %s failed: %v", branch, repo.FullName, err)
+		}
+	}
+}
+
+// GetBranches lists the branch patterns of a repository and the watermarks of every branch fetched so far
+func (h *AppHandler) GetBranches(gc *gin.Context) {
+	repo, err := h.RepositoryRepo.FindByName(gc.Query("repo"))
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	branches, err := h.RepositoryRepo.FindBranches(repo.ID)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	utils.InfoResponse(gc, "success", types.BranchesResponse{
+		DefaultBranch: repo.DefaultBranch,
+		Patterns:      repo.BranchPatterns,
+		Branches:      branches,
+	}, http.StatusOK)
+}
+
+// SetBranchPatterns replaces the branch patterns of a repository. Branches
+// matched from now on are fetched on the next sync.
+func (h *AppHandler) SetBranchPatterns(gc *gin.Context) {
+	var req types.BranchPatternsRequest
+	if err := gc.ShouldBindJSON(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	patterns := make([]string, 0, len(req.Patterns))
+	for _, pattern := range req.Patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			utils.InfoResponse(gc, "Invalid branch pattern "+pattern, nil, http.StatusBadRequest)
+			return
+		}
+		patterns = append(patterns, pattern)
+	}
+	repo, err := h.RepositoryRepo.FindByName(gc.Query("repo"))
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	if err := h.RepositoryRepo.UpdateBranchPatterns(repo.ID, patterns); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	utils.InfoResponse(gc, "success", patterns, http.StatusOK)
+}
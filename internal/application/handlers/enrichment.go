@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/events"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// enrichBatchSize is how many pending commits are loaded at a time
+const enrichBatchSize = 50
+
+// EnrichCommits fetches the single commit endpoint for every commit of repo not
+// enriched yet and stores its line stats and files. Progress lives in the
+// database, so an interrupted run picks up where it stopped. Requests go through
+// the shared GitHub client and so draw on the same rate limit as commit fetching.
+// The run stops at the next batch once enrichment is turned off for repo.
+func (h *AppHandler) EnrichCommits(repo *models.Repository) error {
+	if !h.enrichments.acquire(repo.FullName) {
+		h.logger.Sugar().Info("Enrichment already running for repo:: ", repo.FullName)
+		return nil
+	}
+	defer h.enrichments.release(repo.FullName)
+
+	total := 0
+	for {
+		// the setting is read again before each batch, so turning it off stops the run
+		stored, err := h.RepositoryRepo.FindByName(repo.FullName)
+		if err != nil {
+			return err
+		}
+		if !stored.EnrichCommits {
+			h.logger.Sugar().Infof("Enrichment of %s disabled, stopping after %d commits", repo.FullName, total)
+			return nil
+		}
+		commits, err := h.CommitRepo.FindUnenriched(repo.ID, enrichBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(commits) == 0 {
+			break
+		}
+		for i := range commits {
+			if err := h.enrichCommit(repo, &commits[i]); err != nil {
+				return fmt.Errorf("enriching %s: %w", commits[i].Hash, err)
+			}
+			total++
+		}
+		h.logger.Sugar().Infof("Enriched %d commits of %s", total, repo.FullName)
+	}
+	return nil
+}
+
+func (h *AppHandler) enrichCommit(repo *models.Repository, cmt *models.Commit) error {
+	detail, err := h.GithubService.FetchCommitDetail(repo.FullName, cmt.Hash)
+	if errors.Is(err, ports.ErrNotFound) {
+		// the commit no longer exists upstream; mark it so it is not retried forever
+		h.logger.Sugar().Warn("Commit not found upstream, skipping enrichment: ", cmt.Hash)
+		return h.CommitRepo.SaveEnrichment(cmt, nil)
+	}
+	if err != nil {
+		return err
+	}
+	if detail.Stats != nil {
+		cmt.Additions = detail.Stats.Additions
+		cmt.Deletions = detail.Stats.Deletions
+		cmt.TotalChanges = detail.Stats.Total
+	}
+	files := make([]models.CommitFile, 0, len(detail.Files))
+	for _, f := range detail.Files {
+		files = append(files, f.ToCommitFile(repo.ID, cmt.ID))
+	}
+	return h.CommitRepo.SaveEnrichment(cmt, files)
+}
+
+func (h *AppHandler) HandleEnrichCommitsEvent(event events.EnrichCommitsEvent) {
+	h.logger.Sugar().Info("Received EnrichCommitsEvent repo:: ", event.Repo.FullName)
+	if err := h.EnrichCommits(event.Repo); err != nil {
+		h.logger.Sugar().Error("EnrichCommits error: ", err)
+	}
+}
+
+// emitEnrichment queues enrichment of repo's new commits when it is enabled for the repository
+func (h *AppHandler) emitEnrichment(repoName string) {
+	repo, err := h.RepositoryRepo.FindByName(repoName)
+	if err != nil || !repo.EnrichCommits {
+		return
+	}
+	h.EventBus.Emit(events.EnrichCommitsEvent{Repo: repo})
+	h.logger.Sugar().Info("::::: EnrichCommitsEvent Emitted for repo:: ", repo.FullName)
+}
+
+// SetCommitEnrichment turns the background enrichment of a repository on or off
+func (h *AppHandler) SetCommitEnrichment(gc *gin.Context) {
+	repoName := gc.Query("repo")
+	if repoName == "" {
+		utils.InfoResponse(gc, "Missing repo param", nil, http.StatusBadRequest)
+		return
+	}
+	enabled, err := strconv.ParseBool(gc.DefaultQuery("enabled", "true"))
+	if err != nil {
+		utils.InfoResponse(gc, "Invalid enabled param", nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(repoName)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	if err := h.RepositoryRepo.UpdateEnrichCommits(repo.ID, enabled); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	if enabled {
+		h.emitEnrichment(repoName)
+	}
+	utils.InfoResponse(gc, "success", nil, http.StatusOK)
+}
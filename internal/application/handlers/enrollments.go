@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// Enroll saves the validated enrollment of an owner, syncs it and starts fetching the repositories it added
+func (h *AppHandler) Enroll(req types.EnrollmentRequest) (*types.EnrollmentSyncResult, error) {
+	enrollment := req.ToEnrollment()
+	if err := h.EnrollmentRepo.Save(enrollment); err != nil {
+		return nil, err
+	}
+	result, err := h.SyncEnrollment(enrollment)
+	if err != nil {
+		return nil, err
+	}
+	var repos []*models.Repository
+	for _, name := range append(result.Added, result.Reactivated...) {
+		repo, err := h.RepositoryRepo.FindByName(name)
+		if err != nil {
+			h.logger.Sugar().Error("Error loading enrolled repo: ", err)
+			continue
+		}
+		repos = append(repos, repo)
+	}
+	go h.syncRepositories(repos)
+	return result, nil
+}
+
+// SyncEnrollment stores the repositories of an enrolled owner matching its
+// filters that are not stored yet, and deactivates the enrolled ones that were
+// deleted or no longer match. Repositories added on their own before are
+// adopted by the enrollment. Fetching the commits of new repositories is left
+// to the caller.
+func (h *AppHandler) SyncEnrollment(enrollment *models.Enrollment) (*types.EnrollmentSyncResult, error) {
+	listed, isOrg, err := h.GithubService.ListOwnerRepositories(enrollment.Owner)
+	if err != nil {
+		return nil, fmt.Errorf("listing repositories of %s: %w", enrollment.Owner, err)
+	}
+	result := &types.EnrollmentSyncResult{Owner: enrollment.Owner, Listed: len(listed)}
+	matches, err := enrollment.Matcher()
+	if err != nil {
+		return nil, fmt.Errorf("enrollment of %s: invalid name regex: %w", enrollment.Owner, err)
+	}
+	matching := make(map[string]struct{})
+	for i := range listed {
+		meta := &listed[i]
+		if !matches(meta) {
+			continue
+		}
+		matching[meta.FullName] = struct{}{}
+		stored, err := h.RepositoryRepo.FindByName(meta.FullName)
+		if err != nil {
+			meta.EnrollmentID = enrollment.ID
+			if err := h.createRepository(meta); err != nil {
+				return nil, err
+			}
+			result.Added = append(result.Added, meta.FullName)
+			continue
+		}
+		if stored.EnrollmentID != enrollment.ID {
+			if err := h.RepositoryRepo.UpdateEnrollment(stored.ID, enrollment.ID); err != nil {
+				return nil, err
+			}
+		}
+		if stored.Status == models.RepositoryInactive {
+			if err := h.RepositoryRepo.UpdateStatus(stored.ID, models.RepositoryActive); err != nil {
+				return nil, err
+			}
+			result.Reactivated = append(result.Reactivated, stored.FullName)
+		}
+	}
+	result.Matched = len(matching)
+
+	enrolled, err := h.RepositoryRepo.FindByEnrollment(enrollment.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range enrolled {
+		if _, ok := matching[repo.FullName]; ok || repo.Status == models.RepositoryInactive {
+			continue
+		}
+		if err := h.RepositoryRepo.UpdateStatus(repo.ID, models.RepositoryInactive); err != nil {
+			return nil, err
+		}
+		result.Deactivated = append(result.Deactivated, repo.FullName)
+	}
+
+	if err := h.EnrollmentRepo.MarkSynced(enrollment.ID, isOrg, time.Now()); err != nil {
+		return nil, err
+	}
+	h.logger.Sugar().Infof("Enrollment of %s synced: %d listed, %d matching, %d added, %d reactivated, %d deactivated",
+		enrollment.Owner, result.Listed, result.Matched, len(result.Added), len(result.Reactivated), len(result.Deactivated))
+	return result, nil
+}
+
+// syncEnrollments syncs every enrollment, logging failures, so the monitor picks up new repositories
+func (h *AppHandler) syncEnrollments() {
+	enrollments, err := h.EnrollmentRepo.FindAll()
+	if err != nil {
+		h.logger.Sugar().Error("Error loading enrollments: ", err)
+		return
+	}
+	for i := range enrollments {
+		if _, err := h.SyncEnrollment(&enrollments[i]); err != nil {
+			h.logger.Sugar().Error("SyncEnrollment error: ", err)
+		}
+	}
+}
+
+func (h *AppHandler) CreateEnrollment(gc *gin.Context) {
+	var req types.EnrollmentRequest
+	if err := gc.ShouldBindJSON(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if err := utils.ValidateEnrollment(req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	result, err := h.Enroll(req)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, githubErrorStatus(gc, err))
+		return
+	}
+	utils.InfoResponse(gc, "success", result, http.StatusOK)
+}
+
+func (h *AppHandler) ListEnrollments(gc *gin.Context) {
+	enrollments, err := h.EnrollmentRepo.FindAll()
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	utils.InfoResponse(gc, "success", enrollments, http.StatusOK)
+}
+
+func (h *AppHandler) DeleteEnrollment(gc *gin.Context) {
+	if err := h.EnrollmentRepo.Delete(gc.Param("owner")); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	utils.InfoResponse(gc, "success", nil, http.StatusOK)
+}
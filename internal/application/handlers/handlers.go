@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,18 +18,67 @@ import (
 type AppHandler struct {
 	RepositoryRepo    ports.Repository
 	CommitRepo        ports.Commit
+	IdentityRepo      ports.Identity
+	PullRequestRepo   ports.PullRequest
+	IssueRepo         ports.Issue
+	ReleaseRepo       ports.Release
+	StatsRepo         ports.Stats
+	WorkflowRepo      ports.Workflow
+	EnrollmentRepo    ports.Enrollment
 	GithubService     ports.GithubService
 	EventBus          *events.EventBus
+	Backfill          BackfillOptions
+	EnrichNewRepos    bool
 	logger            *zap.Logger
 	monitoringRunning bool
+	backfills         backfillTracker
+	enrichments       repoRuns
+	syncs             repoRuns
+	syncSlots         chan struct{}
 }
 
-func NewAppHandler(repo ports.Repository, cmt ports.Commit, gh ports.GithubService, logger *zap.Logger) *AppHandler {
+// syncWorkers is how many repositories the monitor cycle and enrollment imports sync at a time
+const syncWorkers = 2
+
+// repoRuns makes sure only one run of a job is in flight per repository
+type repoRuns struct {
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+func (r *repoRuns) acquire(repoName string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running == nil {
+		r.running = make(map[string]bool)
+	}
+	if r.running[repoName] {
+		return false
+	}
+	r.running[repoName] = true
+	return true
+}
+
+func (r *repoRuns) release(repoName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.running, repoName)
+}
+
+func NewAppHandler(repo ports.Repository, cmt ports.Commit, identity ports.Identity, pr ports.PullRequest, issue ports.Issue, release ports.Release, stats ports.Stats, workflow ports.Workflow, enrollment ports.Enrollment, gh ports.GithubService, logger *zap.Logger) *AppHandler {
 	return &AppHandler{
-		RepositoryRepo: repo,
-		CommitRepo:     cmt,
-		GithubService:  gh,
-		logger:         logger,
+		RepositoryRepo:  repo,
+		CommitRepo:      cmt,
+		IdentityRepo:    identity,
+		PullRequestRepo: pr,
+		IssueRepo:       issue,
+		ReleaseRepo:     release,
+		StatsRepo:       stats,
+		WorkflowRepo:    workflow,
+		EnrollmentRepo:  enrollment,
+		GithubService:   gh,
+		logger:          logger,
+		syncSlots:       make(chan struct{}, syncWorkers),
 	}
 }
 
@@ -43,6 +93,18 @@ func (h *AppHandler) SetupEventBus() {
 		e := event.(events.StartMonitorEvent)
 		h.HandleStartMonitoringEvent(e)
 	})
+	eventBus.Register("EnrichCommitsEvent", func(event events.Event) {
+		e := event.(events.EnrichCommitsEvent)
+		h.HandleEnrichCommitsEvent(e)
+	})
+	eventBus.Register("RebuildIdentitiesEvent", func(event events.Event) {
+		e := event.(events.RebuildIdentitiesEvent)
+		h.HandleRebuildIdentitiesEvent(e)
+	})
+	eventBus.Register("HistoryRewrittenEvent", func(event events.Event) {
+		e := event.(events.HistoryRewrittenEvent)
+		h.HandleHistoryRewrittenEvent(e)
+	})
 	h.EventBus = eventBus
 }
 
@@ -75,24 +137,46 @@ func (h *AppHandler) InitNewRepository(repoName string) (bool, error) {
 		EndDate:   config.Env.END_DATE,
 	}
 	if repo, err := h.RepositoryRepo.FindByName(repoName); err == nil {
-		cmtConfig.Sha = repo.LastCommitSHA
-	} else {
-		if err := h.RepositoryRepo.Create(repoMeta); err != nil {
-			// todo: add specific check for already exist error
-			h.logger.Sugar().Error("err:", err.Error())
-			return false, fmt.Errorf("false initializing repo: %s", err)
+		if repoMeta.DefaultBranch != "" && repoMeta.DefaultBranch != repo.DefaultBranch {
+			if err := h.RepositoryRepo.UpdateDefaultBranch(repo.ID, repoMeta.DefaultBranch); err != nil {
+				h.logger.Sugar().Warn("Error updating default branch: ", err)
+			}
+		}
+		if repoMeta.InstallationID != 0 && repoMeta.InstallationID != repo.InstallationID {
+			if err := h.RepositoryRepo.UpdateInstallationID(repo.ID, repoMeta.InstallationID); err != nil {
+				h.logger.Sugar().Warn("Error updating installation id: ", err)
+			}
+		}
+		if !monitored(repo.Status) {
+			h.logger.Sugar().Infof("Repo %s is %s, not syncing it", repo.FullName, repo.Status)
+			return true, nil
 		}
+	} else if err := h.createRepository(repoMeta); err != nil {
+		return false, err
 	}
 	h.EventBus.Emit(events.AddCommitEvent{Repo: repoMeta, Config: cmtConfig})
 	h.logger.Sugar().Info("::::: AddCommitEvent Emitted for repo:: ", repoMeta.FullName)
 	return true, nil
 }
 
+// createRepository stores a repository that is not monitored yet
+func (h *AppHandler) createRepository(repoMeta *models.Repository) error {
+	repoMeta.EnrichCommits = h.EnrichNewRepos
+	repoMeta.Status = models.RepositoryActive
+	if err := h.RepositoryRepo.Create(repoMeta); err != nil {
+		// todo: add specific check for already exist error
+		h.logger.Sugar().Error("err:", err.Error())
+		return fmt.Errorf("false initializing repo: %s", err)
+	}
+	return nil
+}
+
 func (h *AppHandler) UpdateAllCommits() error {
 	err := utils.ValidateDates(config.Env.START_DATE, config.Env.END_DATE)
 	if err != nil {
 		return err
 	}
+	h.syncEnrollments()
 	repos, err := h.RepositoryRepo.FindAll()
 	if err != nil {
 		return err
@@ -100,50 +184,45 @@ func (h *AppHandler) UpdateAllCommits() error {
 	if len(repos) < 1 {
 		return fmt.Errorf("no repository added yet. add repo to fetch commits")
 	}
+	var due []*models.Repository
 	for _, repo := range repos {
-		cmtConfig := models.CommitConfig{
-			StartDate: config.Env.START_DATE,
-			EndDate:   config.Env.END_DATE,
-			Sha:       repo.LastCommitSHA,
+		if monitored(repo.Status) {
+			due = append(due, repo)
 		}
-		h.EventBus.Emit(events.AddCommitEvent{Repo: repo, Config: cmtConfig})
-		h.logger.Sugar().Info("::::: AddCommitEvent Emitted for repo:: ", repo.FullName)
 	}
+	go h.syncRepositories(due)
 	return nil
 }
 
-func (h *AppHandler) CommitManager(repo *models.Repository, config models.CommitConfig) error {
-
-	for {
-		commits, lastCommitSHA, rateLimitDuration, err := h.GithubService.FetchCommits(repo.FullName, repo.ID, config)
-		if err != nil {
-			return err
-		}
-
-		if len(commits) == 0 {
-			break
-		}
-
-		if err := h.insertCommitBatch(commits); err != nil {
-			return err
-		}
-
-		if lastCommitSHA == "" {
-			break
-		}
-		if err := h.RepositoryRepo.UpdateLastCommitSHA(repo.ID, lastCommitSHA); err != nil {
-			return err
-		}
+// syncRepositories syncs repos one after the other on at most syncWorkers
+// workers, shared by the monitor cycle and enrollment imports, so a large
+// enrollment does not start a sync for every repository at once.
+func (h *AppHandler) syncRepositories(repos []*models.Repository) {
+	cmtConfig := models.CommitConfig{
+		StartDate: config.Env.START_DATE,
+		EndDate:   config.Env.END_DATE,
+	}
+	for _, repo := range repos {
+		h.syncSlots <- struct{}{}
+		go func(repo *models.Repository) {
+			defer func() { <-h.syncSlots }()
+			h.HandleAddCommitEvent(events.AddCommitEvent{Repo: repo, Config: cmtConfig})
+		}(repo)
+	}
+}
 
-		config.Sha = lastCommitSHA
-		if count, err := h.CommitRepo.Count(); err == nil {
-			h.logger.Sugar().Info("Total Commit in Database  ", count)
-		}
-		if rateLimitDuration > 1 {
-			time.Sleep(time.Duration(rateLimitDuration))
+// CommitManager syncs the default branch of a repository from its stored
+// watermarks, storing each page and advancing the matching watermark together
+// so a crash never loses more than the page in flight.
+func (h *AppHandler) CommitManager(repo *models.Repository, config models.CommitConfig) error {
+	state := branchSync{Name: repo.DefaultBranch}
+	if stored, err := h.RepositoryRepo.FindByName(repo.FullName); err == nil {
+		state.HeadSHA, state.HeadDate, state.BackfillSHA = stored.HeadSHA, stored.HeadDate, stored.LastCommitSHA
+		if state.Name == "" {
+			state.Name = stored.DefaultBranch
 		}
 	}
-	return nil
+	return h.syncBranch(repo, state, config)
 }
 
 func (h *AppHandler) TriggerMonitorCommits(gc *gin.Context) {
@@ -175,10 +254,25 @@ func (h *AppHandler) HandleAddCommitEvent(event events.AddCommitEvent) {
 	repo := event.Repo
 	config := event.Config
 	h.logger.Sugar().Info("Received AddCommitEvent repo:: ", repo.FullName)
+	if !h.syncs.acquire(repo.FullName) {
+		h.logger.Sugar().Info("Sync already running for repo:: ", repo.FullName)
+		return
+	}
+	defer h.syncs.release(repo.FullName)
+	config = h.forkCommitConfig(repo, config)
 	if err := h.CommitManager(repo, config); err != nil {
 		h.logger.Sugar().Error("CommitManager error: ", err)
+		h.updateSyncStatus(repo.FullName, err)
 		return
 	}
+	h.syncBranches(repo.FullName, config)
+	h.syncPullRequests(repo.FullName)
+	h.syncIssues(repo.FullName)
+	h.syncReleases(repo.FullName)
+	h.syncWorkflowRuns(repo.FullName)
+	h.syncStats(repo.FullName)
+	h.updateSyncStatus(repo.FullName, nil)
+	h.emitEnrichment(repo.FullName)
 	if !h.isMonitoringRunning() {
 		h.EventBus.Emit(events.StartMonitorEvent{})
 		h.logger.Sugar().Info("::::::: StartMonitorEvent Emitted for repo:: ", repo.FullName)
@@ -186,17 +280,33 @@ func (h *AppHandler) HandleAddCommitEvent(event events.AddCommitEvent) {
 	}
 }
 
+// forkCommitConfig starts the commit import of a fork at its creation when its
+// parent is monitored too, so the history they share is stored once, with the
+// parent. A fork whose parent is not monitored gets its full history.
+func (h *AppHandler) forkCommitConfig(repo *models.Repository, config models.CommitConfig) models.CommitConfig {
+	if !repo.Fork || repo.CreatedAt.IsZero() {
+		return config
+	}
+	parent := repo.Parent
+	if parent == nil {
+		meta, err := h.GithubService.FetchRepository(repo.FullName)
+		if err != nil || meta.Parent == nil {
+			return config
+		}
+		parent = meta.Parent
+	}
+	if stored, err := h.RepositoryRepo.FindByName(parent.FullName); err != nil || !monitored(stored.Status) {
+		return config
+	}
+	created := repo.CreatedAt.Format("2006-01-02")
+	if config.StartDate == "" || config.StartDate < created {
+		config.StartDate = created
+	}
+	return config
+}
+
 func (h *AppHandler) HandleStartMonitoringEvent(event events.StartMonitorEvent) {
 	h.logger.Sugar().Info("Received StartMonitorEvent Emitted for repo:: ")
 	go h.MonitorCommits()
 	h.logger.Sugar().Info("Started Monitoring all repos")
 }
-
-func (h *AppHandler) insertCommitBatch(batch []models.Commit) error {
-	h.logger.Sugar().Info("Upserting commit")
-	if err := h.CommitRepo.UpsertCommits(batch); err != nil {
-		h.logger.Sugar().Error("Upsert Error", err)
-		return err
-	}
-	return nil
-}
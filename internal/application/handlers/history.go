@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/events"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// checkHistory makes sure the resume cursor of branch is still reachable from
+// the branch head. When the branch was force-pushed or reset, the commits only
+// reachable from the cursor are marked orphaned, the rewrite is recorded and
+// announced, and an empty cursor is returned so the branch is synced again from
+// its new head. Failing checks are logged and keep the cursor.
+func (h *AppHandler) checkHistory(repo *models.Repository, branch string, cursor string) string {
+	if cursor == "" || branch == "" {
+		return cursor
+	}
+	head, err := h.GithubService.FetchBranch(repo.FullName, branch)
+	if err != nil {
+		h.logger.Sugar().Warnf("Could not check history of %s on %s: %v", branch, repo.FullName, err)
+		return cursor
+	}
+	if head.Commit.SHA == cursor {
+		return cursor
+	}
+
+	rewrite := models.HistoryRewrite{
+		RepoID:     repo.ID,
+		Branch:     branch,
+		Protected:  head.Protected,
+		OldSHA:     cursor,
+		NewHeadSHA: head.Commit.SHA,
+	}
+	// only the status is needed here; the commits are listed once a rewrite is found
+	comparison, err := h.GithubService.CompareStatus(repo.FullName, cursor, head.Commit.SHA)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		rewrite.Status = models.RewriteMissing
+	case err != nil:
+		h.logger.Sugar().Warnf("Could not check history of %s on %s: %v", branch, repo.FullName, err)
+		return cursor
+	case comparison.Status == models.RewriteBehind || comparison.Status == models.RewriteDiverged:
+		rewrite.Status = comparison.Status
+		rewrite.MergeBaseSHA = comparison.MergeBaseCommit.SHA
+	default:
+		return cursor
+	}
+
+	dropped := []string{cursor}
+	if rewrite.Status != models.RewriteMissing {
+		// the commits reachable from the old cursor but no longer from the head
+		gone, err := h.GithubService.CompareCommits(repo.FullName, head.Commit.SHA, cursor)
+		if err != nil {
+			h.logger.Sugar().Warnf("Could not list commits dropped from %s on %s: %v", branch, repo.FullName, err)
+		} else {
+			for _, cmt := range gone.Commits {
+				if cmt.SHA != cursor {
+					dropped = append(dropped, cmt.SHA)
+				}
+			}
+		}
+	}
+	if err := h.CommitRepo.RecordRewrite(&rewrite, dropped); err != nil {
+		h.logger.Sugar().Error("RecordRewrite error: ", err)
+		return ""
+	}
+	h.logger.Sugar().Warnf("History of %s on %s was rewritten (%s): %s is no longer on %s, %d commits orphaned",
+		branch, repo.FullName, rewrite.Status, cursor, head.Commit.SHA, rewrite.OrphanedCount)
+	h.EventBus.Emit(events.HistoryRewrittenEvent{Repo: repo, Rewrite: rewrite})
+	return ""
+}
+
+func (h *AppHandler) HandleHistoryRewrittenEvent(event events.HistoryRewrittenEvent) {
+	h.logger.Sugar().Info("Received HistoryRewrittenEvent repo:: ", event.Repo.FullName, " branch:: ", event.Rewrite.Branch)
+}
+
+// ListHistoryRewrites returns the audit trail of rewritten branch histories of a repository, newest first
+func (h *AppHandler) ListHistoryRewrites(gc *gin.Context) {
+	var req types.HistoryRewritesRequest
+	if err := gc.ShouldBindQuery(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	pagination, err := utils.ParsePaginationParams(req.Page, req.PageSize)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(req.Repo)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	rewrites, err := h.CommitRepo.FindRewrites(repo.ID, req.Protected, pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	hasNext := len(rewrites) > pagination.PageSize
+	if hasNext {
+		rewrites = rewrites[:pagination.PageSize]
+	}
+	utils.InfoResponse(gc, "success", gin.H{
+		"rewrites": rewrites,
+		"pagination": types.PaginationResponse{
+			Page:     fmt.Sprint(pagination.Page),
+			PageSize: fmt.Sprint(len(rewrites)),
+			HasNext:  hasNext,
+		},
+	}, http.StatusOK)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/events"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// identityRebuildBatch is how many commits are re-resolved per transaction
+const identityRebuildBatch = 500
+
+func (h *AppHandler) ListIdentities(gc *gin.Context) {
+	var req types.PaginationRequest
+	if err := gc.ShouldBindQuery(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	pagination, err := utils.ParsePaginationParams(req.Page, req.PageSize)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	identities, err := h.IdentityRepo.FindAll(pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	hasNext := len(identities) > pagination.PageSize
+	if hasNext {
+		identities = identities[:pagination.PageSize]
+	}
+	utils.InfoResponse(gc, "success", gin.H{
+		"identities": identities,
+		"pagination": types.PaginationResponse{
+			Page:     fmt.Sprint(pagination.Page),
+			PageSize: fmt.Sprint(len(identities)),
+			HasNext:  hasNext,
+		},
+	}, http.StatusOK)
+}
+
+func (h *AppHandler) ListAliasRules(gc *gin.Context) {
+	rules, err := h.IdentityRepo.FindRules()
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	utils.InfoResponse(gc, "success", rules, http.StatusOK)
+}
+
+func (h *AppHandler) CreateAliasRule(gc *gin.Context) {
+	var req types.AliasRuleRequest
+	if err := gc.ShouldBindJSON(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if req.CanonicalName == "" && req.CanonicalEmail == "" {
+		utils.InfoResponse(gc, "canonical_name or canonical_email is required", nil, http.StatusBadRequest)
+		return
+	}
+	rule := &models.AliasRule{
+		MatchName:      strings.TrimSpace(req.MatchName),
+		MatchEmail:     strings.ToLower(strings.TrimSpace(req.MatchEmail)),
+		CanonicalName:  strings.TrimSpace(req.CanonicalName),
+		CanonicalEmail: strings.ToLower(strings.TrimSpace(req.CanonicalEmail)),
+		Source:         models.AliasSourceManual,
+	}
+	if err := h.IdentityRepo.CreateRule(rule); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	h.rebuildIdentities("alias rule added")
+	utils.InfoResponse(gc, "alias rule added, identities are being rebuilt", rule, http.StatusAccepted)
+}
+
+func (h *AppHandler) DeleteAliasRule(gc *gin.Context) {
+	id, err := strconv.ParseUint(gc.Param("id"), 10, 64)
+	if err != nil {
+		utils.InfoResponse(gc, "Invalid alias rule id", nil, http.StatusBadRequest)
+		return
+	}
+	if err := h.IdentityRepo.DeleteRule(uint(id)); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	h.rebuildIdentities("alias rule deleted")
+	utils.InfoResponse(gc, "alias rule deleted, identities are being rebuilt", nil, http.StatusAccepted)
+}
+
+// ImportMailmap replaces the alias rules of one .mailmap file: the request body,
+// or with the repo param that repository's .mailmap on its default branch
+func (h *AppHandler) ImportMailmap(gc *gin.Context) {
+	var (
+		content []byte
+		err     error
+		source  = models.AliasSourceMailmap
+	)
+	if repoName := gc.Query("repo"); repoName != "" {
+		source += ":" + repoName
+		content, err = h.GithubService.FetchFile(repoName, ".mailmap")
+		if err != nil {
+			utils.InfoResponse(gc, err.Error(), nil, githubErrorStatus(gc, err))
+			return
+		}
+	} else {
+		content, err = io.ReadAll(gc.Request.Body)
+		if err != nil {
+			utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+			return
+		}
+	}
+	rules := utils.ParseMailmap(string(content), source)
+	if err := h.IdentityRepo.ReplaceRules(source, rules); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	h.rebuildIdentities(source + " imported")
+	utils.InfoResponse(gc, fmt.Sprintf("%d alias rules imported, identities are being rebuilt", len(rules)), rules, http.StatusAccepted)
+}
+
+func (h *AppHandler) rebuildIdentities(reason string) {
+	h.EventBus.Emit(events.RebuildIdentitiesEvent{Reason: reason})
+	h.logger.Sugar().Info("::::: RebuildIdentitiesEvent Emitted: ", reason)
+}
+
+func (h *AppHandler) HandleRebuildIdentitiesEvent(event events.RebuildIdentitiesEvent) {
+	h.logger.Sugar().Info("Received RebuildIdentitiesEvent: ", event.Reason)
+	processed, err := h.IdentityRepo.Rebuild(identityRebuildBatch)
+	if err != nil {
+		h.logger.Sugar().Error("Rebuilding identities failed: ", err)
+		return
+	}
+	h.logger.Sugar().Infof("Rebuilt identities of %d commits", processed)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// SyncIssues stores the issues of repo updated since the last sync. Pages
+// arrive oldest update first, but the watermark still only moves once every
+// page is stored.
+func (h *AppHandler) SyncIssues(repo *models.Repository) error {
+	var newest time.Time
+	err := h.GithubService.FetchIssues(repo.FullName, repo.ID, repo.IssuesSyncedAt, func(issues []models.Issue) error {
+		for _, issue := range issues {
+			if issue.UpdatedAt.After(newest) {
+				newest = issue.UpdatedAt
+			}
+		}
+		h.logger.Sugar().Info("Upserting issue page of ", len(issues))
+		return h.IssueRepo.UpsertIssues(issues)
+	})
+	if err != nil || newest.IsZero() {
+		return err
+	}
+	return h.RepositoryRepo.UpdateIssuesSyncedAt(repo.ID, newest)
+}
+
+// syncIssues syncs the issues of a stored repository, logging failures
+func (h *AppHandler) syncIssues(repoName string) {
+	repo, err := h.RepositoryRepo.FindByName(repoName)
+	if err != nil {
+		return
+	}
+	if err := h.SyncIssues(repo); err != nil {
+		h.logger.Sugar().Error("SyncIssues error: ", err)
+	}
+}
+
+func (h *AppHandler) FetchIssuesByRepoName(gc *gin.Context) {
+	var req types.FetchIssuesRequest
+	if err := gc.ShouldBindQuery(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if req.RepoName == "" {
+		utils.InfoResponse(gc, "missing repoName", nil, http.StatusBadRequest)
+		return
+	}
+	pagination, err := utils.ParsePaginationParams(req.Page, req.PageSize)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(req.RepoName)
+	if err != nil {
+		h.logger.Sugar().Error("Error finding repository: ", err)
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	issues, err := h.IssueRepo.FindByRepoId(repo.ID, req.IssueFilter, pagination.Page, pagination.PageSize)
+	if err != nil {
+		h.logger.Sugar().Error("Error fetching issues by: ", err)
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	hasNext := false
+	if len(issues) > pagination.PageSize {
+		hasNext = true
+	}
+	pageLen := int(math.Min(float64(pagination.PageSize), float64(len(issues))))
+	resp := types.FetchIssuesResponse{
+		Issues: issues[:pageLen],
+		Pagination: types.PaginationResponse{
+			Page:     fmt.Sprint(pagination.Page),
+			PageSize: fmt.Sprint(pageLen),
+			HasNext:  hasNext,
+		},
+	}
+	utils.InfoResponse(gc, "success", resp, http.StatusOK)
+}
+
+// GetIssue returns an issue with the stored commits referencing it
+func (h *AppHandler) GetIssue(gc *gin.Context) {
+	number, err := strconv.Atoi(gc.Param("number"))
+	if err != nil {
+		utils.InfoResponse(gc, "Invalid issue number", nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(gc.Query("repo_name"))
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	issue, err := h.IssueRepo.FindByNumber(repo.ID, number)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	commits, err := h.IssueRepo.FindReferencingCommits(repo.ID, number)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	utils.InfoResponse(gc, "success", types.IssueResponse{Issue: issue, Commits: commits}, http.StatusOK)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+const (
+	// defaultMetricsDays is the range reported when no from date is given
+	defaultMetricsDays = 30
+	defaultPercentiles = "50,90"
+)
+
+// GetReviewMetrics reports review cycle times of the pull requests opened in
+// a date range, as percentiles in hours, and the review load of each reviewer
+// over the same range.
+func (h *AppHandler) GetReviewMetrics(gc *gin.Context) {
+	var req types.ReviewMetricsRequest
+	if err := gc.ShouldBindQuery(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	window, err := utils.ParseDateRange(req.From, req.To, defaultMetricsDays)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if req.Percentiles == "" {
+		req.Percentiles = defaultPercentiles
+	}
+	percentiles, err := utils.ParsePercentiles(req.Percentiles)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(gc.Param("owner") + "/" + gc.Param("repo"))
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+
+	timings, err := h.PullRequestRepo.FindReviewTimings(repo.ID, window)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	reviewers, err := h.PullRequestRepo.GetReviewLoad(repo.ID, window)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	var firstReview, approve, merge []float64
+	for _, t := range timings {
+		if t.FirstReviewAt != nil {
+			firstReview = append(firstReview, t.FirstReviewAt.Sub(t.CreatedAt).Hours())
+		}
+		if t.ApprovedAt != nil {
+			approve = append(approve, t.ApprovedAt.Sub(t.CreatedAt).Hours())
+		}
+		if t.MergedAt != nil {
+			merge = append(merge, t.MergedAt.Sub(t.CreatedAt).Hours())
+		}
+	}
+	utils.InfoResponse(gc, "success", types.ReviewMetricsResponse{
+		Repo:              repo.FullName,
+		From:              window.Since,
+		To:                window.Until,
+		PullRequests:      len(timings),
+		TimeToFirstReview: types.DurationStats{Samples: len(firstReview), Hours: utils.Percentiles(firstReview, percentiles)},
+		TimeToApprove:     types.DurationStats{Samples: len(approve), Hours: utils.Percentiles(approve, percentiles)},
+		TimeToMerge:       types.DurationStats{Samples: len(merge), Hours: utils.Percentiles(merge, percentiles)},
+		Reviewers:         reviewers,
+	}, http.StatusOK)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// pullsSyncOverlap is how far before the watermark pull requests are fetched
+// again. The list is paged most recently updated first, so pull requests
+// updated during a sync shift the pages and one can be passed over.
+const pullsSyncOverlap = time.Hour
+
+// SyncPullRequests stores the pull requests of repo updated since the last
+// sync, with their reviews and review comments. The list endpoint has no line
+// stats, so each pull request is fetched once more for them. The watermark
+// only moves after every page is stored.
+func (h *AppHandler) SyncPullRequests(repo *models.Repository) error {
+	since := repo.PullsSyncedAt
+	if since != nil {
+		overlap := since.Add(-pullsSyncOverlap)
+		since = &overlap
+	}
+	var newest time.Time
+	err := h.GithubService.FetchPullRequests(repo.FullName, repo.ID, since, func(prs []models.PullRequest) error {
+		for i := range prs {
+			detail, err := h.GithubService.FetchPullRequest(repo.FullName, prs[i].Number)
+			if err != nil {
+				return fmt.Errorf("fetching pull request #%d: %w", prs[i].Number, err)
+			}
+			prs[i].Additions, prs[i].Deletions = detail.Additions, detail.Deletions
+			if prs[i].UpdatedAt.After(newest) {
+				newest = prs[i].UpdatedAt
+			}
+		}
+		h.logger.Sugar().Info("Upserting pull request page of ", len(prs))
+		if err := h.PullRequestRepo.UpsertPullRequests(prs); err != nil {
+			return err
+		}
+		for _, pr := range prs {
+			if err := h.syncReviews(repo, pr.Number); err != nil {
+				return fmt.Errorf("fetching reviews of pull request #%d: %w", pr.Number, err)
+			}
+		}
+		return nil
+	})
+	if err != nil || newest.IsZero() {
+		return err
+	}
+	return h.RepositoryRepo.UpdatePullsSyncedAt(repo.ID, newest)
+}
+
+func (h *AppHandler) syncReviews(repo *models.Repository, number int) error {
+	reviews, err := h.GithubService.FetchPullRequestReviews(repo.FullName, repo.ID, number)
+	if err != nil {
+		return err
+	}
+	comments, err := h.GithubService.FetchReviewComments(repo.FullName, repo.ID, number)
+	if err != nil {
+		return err
+	}
+	return h.PullRequestRepo.SaveReviews(repo.ID, number, reviews, comments)
+}
+
+// syncPullRequests syncs the pull requests of a stored repository, logging failures
+func (h *AppHandler) syncPullRequests(repoName string) {
+	repo, err := h.RepositoryRepo.FindByName(repoName)
+	if err != nil {
+		return
+	}
+	if err := h.SyncPullRequests(repo); err != nil {
+		h.logger.Sugar().Error("SyncPullRequests error: ", err)
+	}
+}
+
+func (h *AppHandler) FetchPullRequestsByRepoName(gc *gin.Context) {
+	var req types.FetchPullRequestsRequest
+	if err := gc.ShouldBindQuery(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if req.RepoName == "" {
+		utils.InfoResponse(gc, "missing repoName", nil, http.StatusBadRequest)
+		return
+	}
+	pagination, err := utils.ParsePaginationParams(req.Page, req.PageSize)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(req.RepoName)
+	if err != nil {
+		h.logger.Sugar().Error("Error finding repository: ", err)
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	prs, err := h.PullRequestRepo.FindByRepoId(repo.ID, req.PullRequestFilter, pagination.Page, pagination.PageSize)
+	if err != nil {
+		h.logger.Sugar().Error("Error fetching pull requests by: ", err)
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	hasNext := false
+	if len(prs) > pagination.PageSize {
+		hasNext = true
+	}
+	pageLen := int(math.Min(float64(pagination.PageSize), float64(len(prs))))
+	resp := types.FetchPullRequestsResponse{
+		PullRequests: prs[:pageLen],
+		Pagination: types.PaginationResponse{
+			Page:     fmt.Sprint(pagination.Page),
+			PageSize: fmt.Sprint(pageLen),
+			HasNext:  hasNext,
+		},
+	}
+	utils.InfoResponse(gc, "success", resp, http.StatusOK)
+}
+
+func (h *AppHandler) GetPullRequest(gc *gin.Context) {
+	number, err := strconv.Atoi(gc.Param("number"))
+	if err != nil {
+		utils.InfoResponse(gc, "Invalid pull request number", nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(gc.Query("repo_name"))
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	pr, err := h.PullRequestRepo.FindByNumber(repo.ID, number)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	utils.InfoResponse(gc, "success", pr, http.StatusOK)
+}
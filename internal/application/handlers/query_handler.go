@@ -11,7 +11,7 @@ import (
 )
 
 func (h *AppHandler) GetTopCommitAuthors(gc *gin.Context) {
-	var req types.PaginationRequest
+	var req types.TopCommitAuthorsRequest
 	if err := gc.ShouldBindQuery(&req); err != nil {
 		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
 		return
@@ -22,7 +22,7 @@ func (h *AppHandler) GetTopCommitAuthors(gc *gin.Context) {
 		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
 		return
 	}
-	authors, err := h.CommitRepo.GetTopCommitAuthors(pagination.Page, pagination.PageSize+1)
+	authors, err := h.CommitRepo.GetTopCommitAuthors(pagination.Page, pagination.PageSize+1, req.IncludeCoAuthors)
 	if err != nil {
 		h.logger.Sugar().Warn("Error fetching top commit authors: ", err)
 		return
@@ -64,7 +64,7 @@ func (h *AppHandler) FetchCommitsByRepoName(gc *gin.Context) {
 		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
 		return
 	}
-	commits, err := h.CommitRepo.FindByRepoId(repo.ID, pagination.Page, pagination.PageSize+1)
+	commits, err := h.CommitRepo.FindByRepoId(repo.ID, req.CommitFilter, pagination.Page, pagination.PageSize+1)
 	if err != nil {
 		h.logger.Sugar().Error("Error fetching commits by: ", err)
 		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// SyncReleases replaces the stored tags and releases of repo when they changed
+// since the last sync. Tags are stored first so releases can be pointed at the
+// commits of their tags.
+func (h *AppHandler) SyncReleases(repo *models.Repository) error {
+	err := h.GithubService.FetchTags(repo.FullName, repo.ID, func(tags []models.Tag) error {
+		h.logger.Sugar().Infof("Storing %d tags of %s", len(tags), repo.FullName)
+		return h.ReleaseRepo.SaveTags(repo.ID, tags)
+	})
+	if err != nil {
+		return fmt.Errorf("syncing tags: %w", err)
+	}
+	err = h.GithubService.FetchReleases(repo.FullName, repo.ID, func(releases []models.Release) error {
+		h.logger.Sugar().Infof("Storing %d releases of %s", len(releases), repo.FullName)
+		return h.ReleaseRepo.SaveReleases(repo.ID, releases)
+	})
+	if err != nil {
+		return fmt.Errorf("syncing releases: %w", err)
+	}
+	return nil
+}
+
+// syncReleases syncs the releases of a stored repository, logging failures
+func (h *AppHandler) syncReleases(repoName string) {
+	repo, err := h.RepositoryRepo.FindByName(repoName)
+	if err != nil {
+		return
+	}
+	if err := h.SyncReleases(repo); err != nil {
+		h.logger.Sugar().Error("SyncReleases error: ", err)
+	}
+}
+
+func (h *AppHandler) FetchReleasesByRepoName(gc *gin.Context) {
+	var req types.FetchReleasesRequest
+	if err := gc.ShouldBindQuery(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if req.RepoName == "" {
+		utils.InfoResponse(gc, "missing repoName", nil, http.StatusBadRequest)
+		return
+	}
+	pagination, err := utils.ParsePaginationParams(req.Page, req.PageSize)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(req.RepoName)
+	if err != nil {
+		h.logger.Sugar().Error("Error finding repository: ", err)
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	releases, err := h.ReleaseRepo.FindReleases(repo.ID, pagination.Page, pagination.PageSize)
+	if err != nil {
+		h.logger.Sugar().Error("Error fetching releases by: ", err)
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	hasNext := false
+	if len(releases) > pagination.PageSize {
+		hasNext = true
+	}
+	pageLen := int(math.Min(float64(pagination.PageSize), float64(len(releases))))
+	resp := types.FetchReleasesResponse{
+		Releases: releases[:pageLen],
+		Pagination: types.PaginationResponse{
+			Page:     fmt.Sprint(pagination.Page),
+			PageSize: fmt.Sprint(pageLen),
+			HasNext:  hasNext,
+		},
+	}
+	utils.InfoResponse(gc, "success", resp, http.StatusOK)
+}
+
+// GetReleaseReport lists the commits between a release and the one before it,
+// as given by the compare API, with their stored details and authors
+func (h *AppHandler) GetReleaseReport(gc *gin.Context) {
+	var req types.ReleaseReportRequest
+	if err := gc.ShouldBindQuery(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(req.RepoName)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+
+	var release *models.Release
+	if req.Tag != "" {
+		release, err = h.ReleaseRepo.FindByTag(repo.ID, req.Tag)
+	} else {
+		release, err = h.ReleaseRepo.FindLatest(repo.ID)
+	}
+	if err != nil {
+		utils.InfoResponse(gc, "release not found", nil, http.StatusNotFound)
+		return
+	}
+	var previous *models.Release
+	if req.Base != "" {
+		previous, err = h.ReleaseRepo.FindByTag(repo.ID, req.Base)
+	} else {
+		previous, err = h.ReleaseRepo.FindPrevious(release)
+	}
+	if err != nil {
+		utils.InfoResponse(gc, "no earlier release to compare with", nil, http.StatusNotFound)
+		return
+	}
+
+	comparison, err := h.GithubService.CompareCommits(repo.FullName, releaseRef(previous), releaseRef(release))
+	if err != nil {
+		h.logger.Sugar().Error("CompareCommits error: ", err)
+		utils.InfoResponse(gc, err.Error(), nil, githubErrorStatus(gc, err))
+		return
+	}
+	hashes := make([]string, 0, len(comparison.Commits))
+	for _, cmt := range comparison.Commits {
+		hashes = append(hashes, cmt.SHA)
+	}
+	stored, err := h.CommitRepo.FindByHashes(repo.ID, hashes)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	byHash := make(map[string]*models.Commit, len(stored))
+	for _, cmt := range stored {
+		byHash[cmt.Hash] = cmt
+	}
+
+	report := types.ReleaseReport{Release: release, Previous: previous, Commits: []*models.Commit{}, UnstoredSHAs: []string{}}
+	ids := make([]uint, 0, len(stored))
+	// keep the oldest first order of the compare API
+	for _, hash := range hashes {
+		cmt, ok := byHash[hash]
+		if !ok {
+			report.UnstoredSHAs = append(report.UnstoredSHAs, hash)
+			continue
+		}
+		report.Commits = append(report.Commits, cmt)
+		ids = append(ids, cmt.ID)
+	}
+	report.Authors, err = h.CommitRepo.GetCommitAuthors(ids)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	utils.InfoResponse(gc, "success", report, http.StatusOK)
+}
+
+// releaseRef is what a release is compared by: its commit once the tag is stored, its tag otherwise
+func releaseRef(release *models.Release) string {
+	if release.TargetSHA != "" {
+		return release.TargetSHA
+	}
+	return release.TagName
+}
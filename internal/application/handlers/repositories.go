@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/config"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/events"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// monitored reports whether repositories with status are synced by the monitor
+func monitored(status string) bool {
+	return status != models.RepositoryPaused && status != models.RepositoryInactive
+}
+
+// updateSyncStatus records the outcome of a sync of a repository: error when it
+// failed, else archived or active following GitHub. Paused and inactive
+// repositories keep their status, as it was set while the sync ran.
+func (h *AppHandler) updateSyncStatus(repoName string, syncErr error) {
+	repo, err := h.RepositoryRepo.FindByName(repoName)
+	if err != nil || !monitored(repo.Status) {
+		return
+	}
+	status := models.RepositoryActive
+	if syncErr != nil {
+		status = models.RepositoryError
+	} else if repo.Archived {
+		status = models.RepositoryArchived
+	}
+	if status == repo.Status {
+		return
+	}
+	if err := h.RepositoryRepo.UpdateStatus(repo.ID, status); err != nil {
+		h.logger.Sugar().Error("Error updating repository status: ", err)
+	}
+}
+
+// findRepository looks up the repository named by the owner and repo path
+// parameters, responding 404 when it is not stored
+func (h *AppHandler) findRepository(gc *gin.Context) (*models.Repository, bool) {
+	repo, err := h.RepositoryRepo.FindByName(gc.Param("owner") + "/" + gc.Param("repo"))
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return nil, false
+	}
+	return repo, true
+}
+
+// GetRepositoryByName is GetRepository with the repository in the path
+func (h *AppHandler) GetRepositoryByName(gc *gin.Context) {
+	repo, ok := h.findRepository(gc)
+	if !ok {
+		return
+	}
+	h.respondRepository(gc, repo)
+}
+
+func (h *AppHandler) PauseRepository(gc *gin.Context) {
+	repo, ok := h.findRepository(gc)
+	if !ok {
+		return
+	}
+	if repo.Status == models.RepositoryPaused {
+		utils.InfoResponse(gc, "repository is already paused", nil, http.StatusConflict)
+		return
+	}
+	h.setRepositoryStatus(gc, repo, models.RepositoryPaused)
+}
+
+// ResumeRepository monitors a paused repository again from its stored watermarks
+func (h *AppHandler) ResumeRepository(gc *gin.Context) {
+	repo, ok := h.findRepository(gc)
+	if !ok {
+		return
+	}
+	if repo.Status != models.RepositoryPaused {
+		utils.InfoResponse(gc, "repository is not paused", nil, http.StatusConflict)
+		return
+	}
+	h.setRepositoryStatus(gc, repo, models.RepositoryActive)
+}
+
+func (h *AppHandler) setRepositoryStatus(gc *gin.Context, repo *models.Repository, status string) {
+	if err := h.RepositoryRepo.UpdateStatus(repo.ID, status); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	repo.Status = status
+	utils.InfoResponse(gc, "success", repo, http.StatusOK)
+}
+
+// ResyncRepository syncs a monitored repository in the background right away,
+// without waiting for the next monitor cycle
+func (h *AppHandler) ResyncRepository(gc *gin.Context) {
+	repo, ok := h.findRepository(gc)
+	if !ok {
+		return
+	}
+	if !monitored(repo.Status) {
+		utils.InfoResponse(gc, "repository is "+repo.Status, nil, http.StatusConflict)
+		return
+	}
+	cmtConfig := models.CommitConfig{
+		StartDate: config.Env.START_DATE,
+		EndDate:   config.Env.END_DATE,
+	}
+	h.EventBus.Emit(events.AddCommitEvent{Repo: repo, Config: cmtConfig})
+	h.logger.Sugar().Info("::::: AddCommitEvent Emitted for repo:: ", repo.FullName)
+	utils.InfoResponse(gc, "Resync started", nil, http.StatusAccepted)
+}
+
+// ResetRepositoryCursor clears the watermarks of a repository and its
+// branches, so their commits are imported again at the next sync
+func (h *AppHandler) ResetRepositoryCursor(gc *gin.Context) {
+	repo, ok := h.findRepository(gc)
+	if !ok {
+		return
+	}
+	if err := h.RepositoryRepo.ResetCursors(repo.ID); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	utils.InfoResponse(gc, "success", nil, http.StatusOK)
+}
+
+// DeleteRepository removes a repository with its commits and all other data
+// stored for it. It is refused while the repository is being synced or
+// enriched, since that run would go on storing rows for it.
+func (h *AppHandler) DeleteRepository(gc *gin.Context) {
+	repo, ok := h.findRepository(gc)
+	if !ok {
+		return
+	}
+	// held until the delete is done, so no run starts in between
+	if !h.syncs.acquire(repo.FullName) {
+		utils.InfoResponse(gc, "repository is being synced; try again once the sync finishes", nil, http.StatusConflict)
+		return
+	}
+	defer h.syncs.release(repo.FullName)
+	if !h.enrichments.acquire(repo.FullName) {
+		utils.InfoResponse(gc, "repository commits are being enriched; try again once enrichment finishes", nil, http.StatusConflict)
+		return
+	}
+	defer h.enrichments.release(repo.FullName)
+	enrolled, err := h.enrolled(repo)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	if enrolled {
+		utils.InfoResponse(gc, "repository belongs to an enrollment; pause it or remove the enrollment", nil, http.StatusConflict)
+		return
+	}
+	if err := h.RepositoryRepo.Delete(repo.ID); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	h.logger.Sugar().Info("Deleted repository ", repo.FullName)
+	utils.InfoResponse(gc, "success", nil, http.StatusOK)
+}
+
+// enrolled reports whether repo would be added again by the next sync of its
+// enrollment: the enrollment still exists and the repository still matches it.
+func (h *AppHandler) enrolled(repo *models.Repository) (bool, error) {
+	if repo.EnrollmentID == 0 || repo.Status == models.RepositoryInactive {
+		return false, nil
+	}
+	enrollments, err := h.EnrollmentRepo.FindAll()
+	if err != nil {
+		return false, err
+	}
+	for _, enrollment := range enrollments {
+		if enrollment.ID == repo.EnrollmentID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListRepositories returns the stored repositories, optionally only those with a status
+func (h *AppHandler) ListRepositories(gc *gin.Context) {
+	var req types.ListRepositoriesRequest
+	if err := gc.ShouldBindQuery(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	repos, err := h.RepositoryRepo.FindAll()
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	listed := []*models.Repository{}
+	for _, repo := range repos {
+		if req.Status == "" || repo.Status == req.Status {
+			listed = append(listed, repo)
+		}
+	}
+	utils.InfoResponse(gc, "success", listed, http.StatusOK)
+}
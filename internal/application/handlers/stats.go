@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// defaultHistoryDays is the range of the repository history when no from date is given
+const defaultHistoryDays = 90
+
+// SyncStats refreshes the metadata of repo, recording a snapshot of it, and
+// stores its contributor and commit activity statistics. Statistics GitHub is
+// still computing are left for the next sync.
+func (h *AppHandler) SyncStats(repo *models.Repository) error {
+	meta, err := h.GithubService.FetchRepository(repo.FullName)
+	if err != nil {
+		return err
+	}
+	if err := h.RepositoryRepo.RecordSnapshot(repo.ID, meta, time.Now()); err != nil {
+		return err
+	}
+	if meta.DefaultBranch != "" && meta.DefaultBranch != repo.DefaultBranch {
+		if err := h.RepositoryRepo.UpdateDefaultBranch(repo.ID, meta.DefaultBranch); err != nil {
+			return err
+		}
+	}
+
+	contributors, err := h.GithubService.FetchContributorStats(repo.FullName, repo.ID)
+	switch {
+	case errors.Is(err, ports.ErrComputing):
+		h.logger.Sugar().Info("Contributor statistics of ", repo.FullName, " not ready yet")
+	case err != nil:
+		return err
+	default:
+		if err := h.StatsRepo.SaveContributorStats(repo.ID, contributors); err != nil {
+			return err
+		}
+	}
+	activity, err := h.GithubService.FetchCommitActivity(repo.FullName, repo.ID)
+	switch {
+	case errors.Is(err, ports.ErrComputing):
+		h.logger.Sugar().Info("Commit activity of ", repo.FullName, " not ready yet")
+	case err != nil:
+		return err
+	default:
+		return h.StatsRepo.SaveCommitActivity(repo.ID, activity)
+	}
+	return nil
+}
+
+// syncStats syncs the metadata and statistics of a stored repository, logging failures
+func (h *AppHandler) syncStats(repoName string) {
+	repo, err := h.RepositoryRepo.FindByName(repoName)
+	if err != nil {
+		return
+	}
+	if err := h.SyncStats(repo); err != nil {
+		h.logger.Sugar().Error("SyncStats error: ", err)
+	}
+}
+
+// GetRepositoryHistory returns the snapshots, weekly commit activity and
+// contributor totals of a repository over a date range, for trend graphs
+func (h *AppHandler) GetRepositoryHistory(gc *gin.Context) {
+	var req types.RepositoryHistoryRequest
+	if err := gc.ShouldBindQuery(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	window, err := utils.ParseDateRange(req.From, req.To, defaultHistoryDays)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(gc.Param("owner") + "/" + gc.Param("repo"))
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+
+	resp := types.RepositoryHistoryResponse{DateWindow: window}
+	if resp.Snapshots, err = h.RepositoryRepo.FindSnapshots(repo.ID, window); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	if resp.CommitActivity, err = h.StatsRepo.FindCommitActivity(repo.ID, window); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	if resp.Contributors, err = h.StatsRepo.FindContributorActivity(repo.ID, window); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	utils.InfoResponse(gc, "success", resp, http.StatusOK)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// branchSync is the stored sync state of one branch
+type branchSync struct {
+	// Name is the branch on GitHub; Key is how it is stored, empty for the default branch
+	Name        string
+	Key         string
+	HeadSHA     string
+	HeadDate    *time.Time
+	BackfillSHA string
+}
+
+// syncBranch brings one branch up to date. New commits are fetched forward
+// from the head watermark, then older history is fetched backward from the
+// backfill watermark until the configured start date. A branch without
+// watermarks is imported from its head, and one whose history was rewritten
+// is imported again.
+func (h *AppHandler) syncBranch(repo *models.Repository, state branchSync, config models.CommitConfig) error {
+	cursor := state.HeadSHA
+	if cursor == "" {
+		cursor = state.BackfillSHA
+	}
+	if cursor != "" && h.checkHistory(repo, state.Name, cursor) == "" {
+		state = branchSync{Name: state.Name, Key: state.Key}
+	}
+
+	if state.HeadSHA == "" && state.BackfillSHA == "" {
+		return h.importBranch(repo, state, config)
+	}
+	if state.HeadSHA == "" {
+		// stored before head watermarks were kept: continue from the newest commit stored
+		newest, err := h.CommitRepo.FindNewest(repo.ID, state.Key)
+		if err != nil {
+			return err
+		}
+		if err := h.CommitRepo.AdvanceHead(repo.ID, state.Key, *newest); err != nil {
+			return err
+		}
+		date := newest.CommittedAt()
+		state.HeadSHA, state.HeadDate = newest.Hash, &date
+	}
+	if err := h.fetchNewer(repo, state, config); err != nil {
+		return err
+	}
+	if state.BackfillSHA == "" {
+		return nil
+	}
+	return h.fetchOlder(repo, state, config)
+}
+
+// importBranch fetches a branch for the first time, newest commits first. The
+// head watermark is set from the first page, the backfill watermark follows
+// every page. The default branch may be backfilled in parallel instead.
+func (h *AppHandler) importBranch(repo *models.Repository, state branchSync, config models.CommitConfig) error {
+	if state.Key == "" && h.canBackfill(config) {
+		windows, err := utils.SplitDateRange(config.StartDate, config.EndDate, h.Backfill.withDefaults().Window)
+		if err == nil && len(windows) > 1 {
+			return h.BackfillRepository(repo, windows)
+		}
+	}
+	importConfig := models.CommitConfig{StartDate: config.StartDate, EndDate: config.EndDate, Branch: state.Key}
+	headSet := false
+	return h.GithubService.FetchCommits(repo.FullName, repo.ID, importConfig, func(commits []models.Commit, cursor string) error {
+		if err := h.storePage(repo, state.Key, commits, cursor); err != nil {
+			return err
+		}
+		if headSet {
+			return nil
+		}
+		headSet = true
+		return h.CommitRepo.AdvanceHead(repo.ID, state.Key, commits[0])
+	})
+}
+
+// fetchNewer fetches the commits made since the head watermark. The watermark
+// only moves once every page is stored, so an interrupted run fetches the same
+// range again rather than skipping part of it.
+func (h *AppHandler) fetchNewer(repo *models.Repository, state branchSync, config models.CommitConfig) error {
+	newerConfig := models.CommitConfig{StartDate: config.StartDate, EndDate: config.EndDate, Branch: state.Key}
+	if state.HeadDate != nil {
+		newerConfig.StartDate = state.HeadDate.UTC().Format(time.RFC3339)
+	}
+	var newest *models.Commit
+	err := h.GithubService.FetchCommits(repo.FullName, repo.ID, newerConfig, func(commits []models.Commit, _ string) error {
+		if newest == nil {
+			newest = &commits[0]
+		}
+		return h.storePage(repo, state.Key, commits, "")
+	})
+	if err != nil || newest == nil || newest.Hash == state.HeadSHA {
+		return err
+	}
+	h.logger.Sugar().Infof("Head of %s %s moved to %s", repo.FullName, state.Name, newest.Hash)
+	return h.CommitRepo.AdvanceHead(repo.ID, state.Key, *newest)
+}
+
+// fetchOlder continues the backfill from the oldest commit stored
+func (h *AppHandler) fetchOlder(repo *models.Repository, state branchSync, config models.CommitConfig) error {
+	olderConfig := models.CommitConfig{StartDate: config.StartDate, EndDate: config.EndDate, Sha: state.BackfillSHA, Branch: state.Key}
+	return h.GithubService.FetchCommits(repo.FullName, repo.ID, olderConfig, func(commits []models.Commit, cursor string) error {
+		return h.storePage(repo, state.Key, commits, cursor)
+	})
+}
+
+func (h *AppHandler) storePage(repo *models.Repository, branch string, commits []models.Commit, cursor string) error {
+	h.logger.Sugar().Info("Upserting commit page of ", len(commits))
+	if err := h.CommitRepo.UpsertPage(repo.ID, branch, commits, cursor); err != nil {
+		h.logger.Sugar().Error("Upsert Error", err)
+		return err
+	}
+	if count, err := h.CommitRepo.Count(); err == nil {
+		h.logger.Sugar().Info("Total Commit in Database  ", count)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+)
+
+// pendingRunHold is how long after its creation a run that is not completed
+// holds the workflow run watermark back. Runs stuck queued or waiting would
+// otherwise make every sync fetch all runs created since.
+const pendingRunHold = 3 * 24 * time.Hour
+
+// SyncWorkflowRuns stores the workflow runs of repo created since the last
+// sync, with their jobs. Runs still in progress are fetched again by the next
+// sync, so the watermark stops at the oldest of them created within
+// pendingRunHold; older ones are left as they are.
+func (h *AppHandler) SyncWorkflowRuns(repo *models.Repository) error {
+	var newest, oldestPending time.Time
+	err := h.GithubService.FetchWorkflowRuns(repo.FullName, repo.ID, repo.RunsSyncedAt, func(runs []models.WorkflowRun) error {
+		h.logger.Sugar().Info("Upserting workflow run page of ", len(runs))
+		if err := h.WorkflowRepo.UpsertRuns(runs); err != nil {
+			return err
+		}
+		for _, run := range runs {
+			if run.CreatedAt.After(newest) {
+				newest = run.CreatedAt
+			}
+			if run.Status != models.WorkflowCompleted {
+				if time.Since(run.CreatedAt) >= pendingRunHold {
+					continue
+				}
+				if oldestPending.IsZero() || run.CreatedAt.Before(oldestPending) {
+					oldestPending = run.CreatedAt
+				}
+				continue
+			}
+			jobs, err := h.GithubService.FetchWorkflowJobs(repo.FullName, repo.ID, run.GitHubID)
+			if err != nil {
+				return fmt.Errorf("fetching jobs of workflow run %d: %w", run.GitHubID, err)
+			}
+			if err := h.WorkflowRepo.SaveJobs(run.GitHubID, jobs); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || newest.IsZero() {
+		return err
+	}
+	if !oldestPending.IsZero() {
+		newest = oldestPending
+	}
+	return h.RepositoryRepo.UpdateRunsSyncedAt(repo.ID, newest)
+}
+
+// syncWorkflowRuns syncs the workflow runs of a stored repository, logging failures
+func (h *AppHandler) syncWorkflowRuns(repoName string) {
+	repo, err := h.RepositoryRepo.FindByName(repoName)
+	if err != nil {
+		return
+	}
+	if err := h.SyncWorkflowRuns(repo); err != nil {
+		h.logger.Sugar().Error("SyncWorkflowRuns error: ", err)
+	}
+}
+
+// GetCommitCIStatus returns the workflow runs of a commit, with their jobs, and their combined state
+func (h *AppHandler) GetCommitCIStatus(gc *gin.Context) {
+	repo, err := h.RepositoryRepo.FindByName(gc.Query("repo_name"))
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	sha := gc.Param("sha")
+	runs, err := h.WorkflowRepo.FindRunsByCommit(repo.ID, sha)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	utils.InfoResponse(gc, "success", types.CommitCIStatus{SHA: sha, State: ciState(runs), Runs: runs}, http.StatusOK)
+}
+
+func ciState(runs []models.WorkflowRun) string {
+	if len(runs) == 0 {
+		return types.CIStateNone
+	}
+	state := types.CIStateSuccess
+	for _, run := range runs {
+		switch {
+		case run.Status != models.WorkflowCompleted:
+			return types.CIStatePending
+		case !passed(run.Conclusion):
+			state = types.CIStateFailure
+		}
+	}
+	return state
+}
+
+// passed reports whether a conclusion lets a commit pass
+func passed(conclusion string) bool {
+	return conclusion == models.WorkflowSuccess || conclusion == models.WorkflowNeutral || conclusion == models.WorkflowSkipped
+}
+
+// GetWorkflowMetrics reports the success rate and duration of every workflow
+// over a date range, in total and week by week, for the runs created in it
+func (h *AppHandler) GetWorkflowMetrics(gc *gin.Context) {
+	var req types.WorkflowMetricsRequest
+	if err := gc.ShouldBindQuery(&req); err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	window, err := utils.ParseDateRange(req.From, req.To, defaultMetricsDays)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	if req.Percentiles == "" {
+		req.Percentiles = defaultPercentiles
+	}
+	percentiles, err := utils.ParsePercentiles(req.Percentiles)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusBadRequest)
+		return
+	}
+	repo, err := h.RepositoryRepo.FindByName(gc.Param("owner") + "/" + gc.Param("repo"))
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusNotFound)
+		return
+	}
+	runs, err := h.WorkflowRepo.FindRuns(repo.ID, window, req.Workflow)
+	if err != nil {
+		utils.InfoResponse(gc, err.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+	utils.InfoResponse(gc, "success", types.WorkflowMetricsResponse{
+		Repo:      repo.FullName,
+		From:      window.Since,
+		To:        window.Until,
+		Workflows: workflowTrends(runs, percentiles),
+	}, http.StatusOK)
+}
+
+// workflowTrends groups runs, oldest first, by workflow and by the week, starting on Monday, they were created in
+func workflowTrends(runs []models.WorkflowRun, percentiles []float64) []types.WorkflowTrend {
+	byWorkflow := make(map[string][]models.WorkflowRun)
+	var names []string
+	for _, run := range runs {
+		if _, ok := byWorkflow[run.WorkflowName]; !ok {
+			names = append(names, run.WorkflowName)
+		}
+		byWorkflow[run.WorkflowName] = append(byWorkflow[run.WorkflowName], run)
+	}
+	sort.Strings(names)
+
+	trends := make([]types.WorkflowTrend, 0, len(names))
+	for _, name := range names {
+		trend := types.WorkflowTrend{Workflow: name, WorkflowStats: workflowStats(byWorkflow[name], percentiles)}
+		var week []models.WorkflowRun
+		for i, run := range byWorkflow[name] {
+			week = append(week, run)
+			last := i == len(byWorkflow[name])-1
+			if last || !weekStart(byWorkflow[name][i+1].CreatedAt).Equal(weekStart(run.CreatedAt)) {
+				trend.Weeks = append(trend.Weeks, types.WorkflowWeek{Week: weekStart(run.CreatedAt), WorkflowStats: workflowStats(week, percentiles)})
+				week = nil
+			}
+		}
+		trends = append(trends, trend)
+	}
+	return trends
+}
+
+func workflowStats(runs []models.WorkflowRun, percentiles []float64) types.WorkflowStats {
+	stats := types.WorkflowStats{Runs: len(runs)}
+	decided := 0
+	var durations []float64
+	for _, run := range runs {
+		if run.Status != models.WorkflowCompleted {
+			continue
+		}
+		stats.Completed++
+		if run.StartedAt != nil {
+			durations = append(durations, run.CompletedAt().Sub(*run.StartedAt).Hours())
+		}
+		if run.Conclusion == models.WorkflowCancelled || run.Conclusion == models.WorkflowSkipped {
+			continue
+		}
+		decided++
+		if run.Conclusion == models.WorkflowSuccess {
+			stats.Successes++
+		}
+	}
+	if decided > 0 {
+		stats.SuccessRate = float64(stats.Successes) / float64(decided)
+	}
+	stats.Duration = types.DurationStats{Samples: len(durations), Hours: utils.Percentiles(durations, percentiles)}
+	return stats
+}
+
+// weekStart returns the Monday, in UTC, of the week t falls in
+func weekStart(t time.Time) time.Time {
+	day := t.UTC().Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
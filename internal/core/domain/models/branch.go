@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// RepositoryBranch tracks the sync state of a non-default branch matched by the
+// repository's branch patterns. LastCommitSHA is its backfill cursor.
+type RepositoryBranch struct {
+	ID            uint      `gorm:"primaryKey" json:"-"`
+	RepoID        uint      `gorm:"uniqueIndex:idx_repository_branch;not null" json:"-"`
+	Name          string    `gorm:"uniqueIndex:idx_repository_branch;not null" json:"name"`
+	LastCommitSHA string    `json:"last_commit_sha"`
+	FetchedAt     time.Time `json:"fetched_at"`
+	// the head and backfill watermarks of the branch, as on Repository
+	HeadSHA      string     `gorm:"not null;default:''" json:"head_sha"`
+	HeadDate     *time.Time `json:"head_date"`
+	BackfillDate *time.Time `json:"backfill_date"`
+}
+
+// CommitBranch records that a commit is part of a branch's history
+type CommitBranch struct {
+	CommitID uint   `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	Branch   string `gorm:"primaryKey" json:"branch"`
+	RepoID   uint   `gorm:"index;not null" json:"-"`
+}
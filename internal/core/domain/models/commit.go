@@ -3,16 +3,48 @@ package models
 import "time"
 
 type Commit struct {
-	ID          uint      `gorm:"primaryKey"`
-	RepoID      uint      `gorm:"index;not null"`
-	Hash        string    `gorm:"unique;not null" json:"sha"`
-	Message     string    `gorm:"type:text" json:"message"`
-	Author      string    `json:"author"`
-	AuthorEmail string    `json:"author_email"`
-	Date        time.Time `json:"author_date"`
-	URL         string    `gorm:"type:text" json:"url"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID             uint      `gorm:"primaryKey"`
+	RepoID         uint      `gorm:"index;not null"`
+	Hash           string    `gorm:"unique;not null" json:"sha"`
+	Message        string    `gorm:"type:text" json:"message"`
+	Author         string    `json:"author"`
+	AuthorEmail    string    `json:"author_email"`
+	Date           time.Time `json:"author_date"`
+	AuthorLogin    string    `gorm:"not null;default:''" json:"author_login"`
+	AuthorID       int64     `gorm:"not null;default:0" json:"author_id"`
+	CommitterName  string    `gorm:"not null;default:''" json:"committer"`
+	CommitterEmail string    `gorm:"not null;default:''" json:"committer_email"`
+	CommitterDate  time.Time `json:"committer_date"`
+	CommitterLogin string    `gorm:"not null;default:''" json:"committer_login"`
+	CommitterID    int64     `gorm:"not null;default:0" json:"committer_id"`
+	// AuthorIdentityID is the canonical person behind the author, 0 until resolved
+	AuthorIdentityID uint `gorm:"index;not null;default:0" json:"author_identity_id"`
+	// Parents lists the parent SHAs, first parent first
+	Parents            []string `gorm:"serializer:json" json:"parents"`
+	IsMerge            bool     `gorm:"index;not null;default:false" json:"is_merge"`
+	Verified           bool     `gorm:"index;not null;default:false" json:"verified"`
+	VerificationReason string   `gorm:"not null;default:''" json:"verification_reason"`
+	CommentCount       int      `gorm:"not null;default:0" json:"comment_count"`
+	Additions          int      `gorm:"not null;default:0" json:"additions"`
+	Deletions          int      `gorm:"not null;default:0" json:"deletions"`
+	TotalChanges       int      `gorm:"not null;default:0" json:"total_changes"`
+	// EnrichedAt is set once the commit's files were fetched; nil means pending
+	EnrichedAt *time.Time `gorm:"index" json:"enriched_at"`
+	// Orphaned marks a commit a force push dropped from its branches; it is kept for the audit trail
+	Orphaned   bool       `gorm:"index;not null;default:false" json:"orphaned"`
+	OrphanedAt *time.Time `json:"orphaned_at"`
+	URL        string     `gorm:"type:text" json:"url"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// CommittedAt is when the commit was last committed, which is what GitHub's
+// since and until filter on; the author date is used when it is unknown
+func (c *Commit) CommittedAt() time.Time {
+	if c.CommitterDate.IsZero() {
+		return c.Date
+	}
+	return c.CommitterDate
 }
 
 func NewCommit(repoID uint, hash, message, author, url string, date time.Time) *Commit {
@@ -28,40 +60,102 @@ func NewCommit(repoID uint, hash, message, author, url string, date time.Time) *
 	}
 }
 
+// CommitSignature is the git author or committer recorded in the commit object
+type CommitSignature struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// GitHubUser is the GitHub account linked to a commit author or committer
+type GitHubUser struct {
+	Login string `json:"login"`
+	ID    int64  `json:"id"`
+}
+
+type CommitParent struct {
+	SHA string `json:"sha"`
+}
+
+// CommitVerification is GitHub's check of the commit signature; Reason is
+// "valid", "unsigned", "unknown_key" and so on
+type CommitVerification struct {
+	Verified bool   `json:"verified"`
+	Reason   string `json:"reason"`
+}
+
+type CommitStats struct {
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+	Total     int `json:"total"`
+}
+
 type CommitResponse struct {
 	SHA    string `json:"sha"`
 	NodeID string `json:"node_id"`
 	Commit struct {
-		Author struct {
-			Name  string    `json:"name"`
-			Email string    `json:"email"`
-			Date  time.Time `json:"date"`
-		} `json:"author"`
-		Message      string `json:"message"`
-		URL          string `json:"url"`
-		CommentCount int    `json:"comment_count"`
+		Author       CommitSignature    `json:"author"`
+		Committer    CommitSignature    `json:"committer"`
+		Message      string             `json:"message"`
+		URL          string             `json:"url"`
+		CommentCount int                `json:"comment_count"`
+		Verification CommitVerification `json:"verification"`
 	} `json:"commit"`
-	URL string `json:"url"`
+	URL       string         `json:"url"`
+	Author    *GitHubUser    `json:"author"`
+	Committer *GitHubUser    `json:"committer"`
+	Parents   []CommitParent `json:"parents"`
+	// Stats is only returned by the single commit endpoint and the GraphQL fetcher
+	Stats *CommitStats `json:"stats,omitempty"`
+	// Files is only returned by the single commit endpoint
+	Files []CommitFileResponse `json:"files,omitempty"`
 }
 
 func (c *CommitResponse) ToCommit(repoId uint) Commit {
 	now := time.Now()
 
-	return Commit{
-		RepoID:      repoId,
-		Hash:        c.SHA,
-		Message:     c.Commit.Message,
-		Author:      c.Commit.Author.Name,
-		AuthorEmail: c.Commit.Author.Email,
-		Date:        c.Commit.Author.Date,
-		URL:         c.Commit.URL,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+	cmt := Commit{
+		RepoID:             repoId,
+		Hash:               c.SHA,
+		Message:            c.Commit.Message,
+		Author:             c.Commit.Author.Name,
+		AuthorEmail:        c.Commit.Author.Email,
+		Date:               c.Commit.Author.Date,
+		CommitterName:      c.Commit.Committer.Name,
+		CommitterEmail:     c.Commit.Committer.Email,
+		CommitterDate:      c.Commit.Committer.Date,
+		Parents:            make([]string, 0, len(c.Parents)),
+		IsMerge:            len(c.Parents) > 1,
+		Verified:           c.Commit.Verification.Verified,
+		VerificationReason: c.Commit.Verification.Reason,
+		CommentCount:       c.Commit.CommentCount,
+		URL:                c.Commit.URL,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if c.Stats != nil {
+		cmt.Additions = c.Stats.Additions
+		cmt.Deletions = c.Stats.Deletions
+		cmt.TotalChanges = c.Stats.Total
+	}
+	for _, parent := range c.Parents {
+		cmt.Parents = append(cmt.Parents, parent.SHA)
+	}
+	if c.Author != nil {
+		cmt.AuthorLogin = c.Author.Login
+		cmt.AuthorID = c.Author.ID
+	}
+	if c.Committer != nil {
+		cmt.CommitterLogin = c.Committer.Login
+		cmt.CommitterID = c.Committer.ID
 	}
+	return cmt
 }
 
 type CommitConfig struct {
 	StartDate string
 	EndDate   string
 	Sha       string
+	// Branch is fetched instead of the default branch when set; Sha still takes precedence to resume
+	Branch string
 }
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// CommitFile is one file touched by a commit, as reported by the single commit endpoint
+type CommitFile struct {
+	ID               uint   `gorm:"primaryKey" json:"-"`
+	CommitID         uint   `gorm:"uniqueIndex:idx_commit_file;not null" json:"-"`
+	RepoID           uint   `gorm:"index;not null" json:"-"`
+	Filename         string `gorm:"uniqueIndex:idx_commit_file;not null" json:"filename"`
+	Status           string `json:"status"`
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+	PreviousFilename string `json:"previous_filename,omitempty"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+type CommitFileResponse struct {
+	Filename         string `json:"filename"`
+	Status           string `json:"status"`
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+	PreviousFilename string `json:"previous_filename"`
+}
+
+func (f *CommitFileResponse) ToCommitFile(repoID, commitID uint) CommitFile {
+	return CommitFile{
+		CommitID:         commitID,
+		RepoID:           repoID,
+		Filename:         f.Filename,
+		Status:           f.Status,
+		Additions:        f.Additions,
+		Deletions:        f.Deletions,
+		Changes:          f.Changes,
+		PreviousFilename: f.PreviousFilename,
+	}
+}
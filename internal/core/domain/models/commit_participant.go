@@ -0,0 +1,20 @@
+package models
+
+const (
+	RoleCoAuthor = "co_author"
+	RoleSigner   = "signer"
+	RoleReviewer = "reviewer"
+	RoleReporter = "reporter"
+)
+
+// CommitParticipant is someone other than the author credited by a commit message trailer
+type CommitParticipant struct {
+	ID       uint   `gorm:"primaryKey" json:"-"`
+	CommitID uint   `gorm:"uniqueIndex:idx_commit_participant;not null" json:"-"`
+	RepoID   uint   `gorm:"index;not null" json:"-"`
+	Role     string `gorm:"uniqueIndex:idx_commit_participant;index;not null" json:"role"`
+	Name     string `json:"name"`
+	Email    string `gorm:"uniqueIndex:idx_commit_participant;not null" json:"email"`
+	// IdentityID is the canonical person behind the trailer, 0 until resolved
+	IdentityID uint `gorm:"index;not null;default:0" json:"identity_id"`
+}
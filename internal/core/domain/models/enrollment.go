@@ -0,0 +1,71 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Enrollment visibilities; internal repositories only exist in enterprise organizations
+const (
+	VisibilityAll      = "all"
+	VisibilityPublic   = "public"
+	VisibilityPrivate  = "private"
+	VisibilityInternal = "internal"
+)
+
+// Enrollment monitors every repository of an organization or user matching its filters
+type Enrollment struct {
+	ID    uint   `gorm:"primaryKey" json:"id"`
+	Owner string `gorm:"unique;not null" json:"owner"`
+	// IsOrg is set once the owner is found to be an organization
+	IsOrg           bool       `gorm:"not null;default:false" json:"is_org"`
+	IncludeArchived bool       `gorm:"not null;default:false" json:"include_archived"`
+	IncludeForks    bool       `gorm:"not null;default:false" json:"include_forks"`
+	Visibility      string     `gorm:"not null;default:'all'" json:"visibility"`
+	Language        string     `gorm:"not null;default:''" json:"language"`
+	Topic           string     `gorm:"not null;default:''" json:"topic"`
+	NameRegex       string     `gorm:"not null;default:''" json:"name_regex"`
+	CreatedAt       time.Time  `json:"created_at"`
+	SyncedAt        *time.Time `json:"synced_at"`
+}
+
+// Matcher compiles the filters of the enrollment into a function reporting
+// whether a repository passes them. The name regex is matched against the
+// repository name without its owner.
+func (e *Enrollment) Matcher() (func(repo *Repository) bool, error) {
+	var name *regexp.Regexp
+	if e.NameRegex != "" {
+		var err error
+		if name, err = regexp.Compile(e.NameRegex); err != nil {
+			return nil, err
+		}
+	}
+	return func(repo *Repository) bool {
+		if repo.Archived && !e.IncludeArchived {
+			return false
+		}
+		if repo.Fork && !e.IncludeForks {
+			return false
+		}
+		if e.Visibility != "" && e.Visibility != VisibilityAll && !strings.EqualFold(repo.Visibility, e.Visibility) {
+			return false
+		}
+		if e.Language != "" && !strings.EqualFold(repo.Language, e.Language) {
+			return false
+		}
+		if e.Topic != "" && !containsFold(repo.Topics, e.Topic) {
+			return false
+		}
+		return name == nil || name.MatchString(repo.Name)
+	}, nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
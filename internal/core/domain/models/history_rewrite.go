@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// HistoryRewrite statuses, as reported by the compare API for the stored cursor against the branch head
+const (
+	// RewriteDiverged means the branch was force-pushed onto a different history
+	RewriteDiverged = "diverged"
+	// RewriteBehind means the branch was reset to an ancestor of the cursor
+	RewriteBehind = "behind"
+	// RewriteMissing means the cursor commit no longer exists upstream
+	RewriteMissing = "missing"
+)
+
+// HistoryRewrite is the audit record of a branch whose history no longer
+// contains the commit fetching resumed from
+type HistoryRewrite struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	RepoID    uint   `gorm:"index;not null" json:"repo_id"`
+	Branch    string `gorm:"not null" json:"branch"`
+	Protected bool   `gorm:"index;not null;default:false" json:"protected"`
+	Status    string `gorm:"not null" json:"status"`
+	// OldSHA is the resume cursor that became unreachable
+	OldSHA        string    `gorm:"not null" json:"old_sha"`
+	NewHeadSHA    string    `json:"new_head_sha"`
+	MergeBaseSHA  string    `json:"merge_base_sha"`
+	OrphanedCount int       `json:"orphaned_count"`
+	DetectedAt    time.Time `gorm:"index" json:"detected_at"`
+}
+
+// BranchResponse is a branch as returned by the branches API
+type BranchResponse struct {
+	Name      string `json:"name"`
+	Protected bool   `json:"protected"`
+	Commit    struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+// CompareResponse is the comparison of two commits. Status is "ahead" when
+// head descends from base, "behind" or "diverged" otherwise, or "identical".
+type CompareResponse struct {
+	Status          string `json:"status"`
+	AheadBy         int    `json:"ahead_by"`
+	BehindBy        int    `json:"behind_by"`
+	MergeBaseCommit struct {
+		SHA string `json:"sha"`
+	} `json:"merge_base_commit"`
+	Commits []CommitResponse `json:"commits"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// HTTPCacheEntry holds the validators GitHub returned for a URL so the next
+// request can be made conditional. Body is only kept for responses that must be
+// replayed on 304, such as repository metadata.
+type HTTPCacheEntry struct {
+	ID           uint   `gorm:"primaryKey"`
+	URL          string `gorm:"uniqueIndex;not null"`
+	RepoName     string `gorm:"index"`
+	ETag         string `gorm:"column:etag"`
+	LastModified string
+	Body         []byte
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Identity is one person behind any number of author names and emails. Authors
+// are merged by GitHub user ID, then by email, after applying the alias rules.
+type Identity struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	Name      string          `json:"name"`
+	Email     string          `gorm:"index" json:"email"`
+	Login     string          `json:"login"`
+	GitHubID  int64           `gorm:"column:github_id;index;not null;default:0" json:"github_id"`
+	Aliases   []IdentityAlias `gorm:"foreignKey:IdentityID" json:"aliases"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// IdentityAlias is a name, email and GitHub user ID combination seen on commits
+type IdentityAlias struct {
+	ID         uint   `gorm:"primaryKey" json:"-"`
+	IdentityID uint   `gorm:"index;not null" json:"-"`
+	Name       string `gorm:"uniqueIndex:idx_identity_alias" json:"name"`
+	Email      string `gorm:"uniqueIndex:idx_identity_alias;index" json:"email"`
+	GitHubID   int64  `gorm:"column:github_id;uniqueIndex:idx_identity_alias;index;not null;default:0" json:"github_id"`
+}
+
+const (
+	AliasSourceManual  = "manual"
+	AliasSourceMailmap = "mailmap"
+)
+
+// AliasRule rewrites a commit name and email before identities are resolved,
+// following .mailmap semantics: an empty MatchName matches any name, and empty
+// canonical fields keep the commit's value.
+type AliasRule struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	MatchName      string `json:"match_name"`
+	MatchEmail     string `gorm:"not null" json:"match_email"`
+	CanonicalName  string `json:"canonical_name"`
+	CanonicalEmail string `json:"canonical_email"`
+	// Source is "manual", "mailmap" for uploads or "mailmap:owner/repo" for a repository's .mailmap
+	Source    string    `gorm:"index" json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
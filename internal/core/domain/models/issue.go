@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// Issue mirrors a GitHub issue. The timestamps are GitHub's, not the time the
+// row was stored.
+type Issue struct {
+	ID          uint       `gorm:"primaryKey" json:"-"`
+	RepoID      uint       `gorm:"uniqueIndex:idx_issue_number;not null" json:"-"`
+	Number      int        `gorm:"uniqueIndex:idx_issue_number;not null" json:"number"`
+	Title       string     `gorm:"type:text" json:"title"`
+	State       string     `gorm:"index;not null" json:"state"`
+	StateReason string     `json:"state_reason"`
+	AuthorLogin string     `gorm:"index" json:"author_login"`
+	AuthorID    int64      `json:"author_id"`
+	Assignees   []string   `gorm:"serializer:json" json:"assignees"`
+	Labels      []string   `gorm:"serializer:json" json:"labels"`
+	Milestone   string     `gorm:"index" json:"milestone"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime:false" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime:false;index" json:"updated_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	URL         string     `gorm:"type:text" json:"url"`
+}
+
+// IssueResponse is an issue as returned by the issues API, which lists pull
+// requests too; those carry a pull_request object.
+type IssueResponse struct {
+	Number      int          `json:"number"`
+	Title       string       `json:"title"`
+	State       string       `json:"state"`
+	StateReason string       `json:"state_reason"`
+	User        GitHubUser   `json:"user"`
+	Assignees   []GitHubUser `json:"assignees"`
+	Labels      []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Milestone *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	HTMLURL     string     `json:"html_url"`
+	PullRequest *struct{}  `json:"pull_request"`
+}
+
+func (i *IssueResponse) IsPullRequest() bool {
+	return i.PullRequest != nil
+}
+
+func (i *IssueResponse) ToIssue(repoID uint) Issue {
+	issue := Issue{
+		RepoID:      repoID,
+		Number:      i.Number,
+		Title:       i.Title,
+		State:       i.State,
+		StateReason: i.StateReason,
+		AuthorLogin: i.User.Login,
+		AuthorID:    i.User.ID,
+		Assignees:   make([]string, 0, len(i.Assignees)),
+		Labels:      make([]string, 0, len(i.Labels)),
+		CreatedAt:   i.CreatedAt,
+		UpdatedAt:   i.UpdatedAt,
+		ClosedAt:    i.ClosedAt,
+		URL:         i.HTMLURL,
+	}
+	for _, assignee := range i.Assignees {
+		issue.Assignees = append(issue.Assignees, assignee.Login)
+	}
+	for _, label := range i.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	if i.Milestone != nil {
+		issue.Milestone = i.Milestone.Title
+	}
+	return issue
+}
+
+// CommitReference is an issue or pull request of the same repository that a
+// commit message mentions as #123 or GH-123. Closes is set when the mention
+// follows a closing keyword such as "Fixes".
+type CommitReference struct {
+	ID       uint `gorm:"primaryKey" json:"-"`
+	CommitID uint `gorm:"uniqueIndex:idx_commit_reference;not null" json:"-"`
+	RepoID   uint `gorm:"index:idx_reference_number;not null" json:"-"`
+	Number   int  `gorm:"uniqueIndex:idx_commit_reference;index:idx_reference_number;not null" json:"number"`
+	Closes   bool `gorm:"not null;default:false" json:"closes"`
+}
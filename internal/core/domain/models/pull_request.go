@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// PullRequest states, as GitHub reports them; a merged pull request is closed
+const (
+	PullRequestOpen   = "open"
+	PullRequestClosed = "closed"
+)
+
+// PullRequest mirrors a GitHub pull request. The timestamps are GitHub's, not
+// the time the row was stored.
+type PullRequest struct {
+	ID             uint       `gorm:"primaryKey" json:"-"`
+	RepoID         uint       `gorm:"uniqueIndex:idx_pull_request_number;not null" json:"-"`
+	Number         int        `gorm:"uniqueIndex:idx_pull_request_number;not null" json:"number"`
+	Title          string     `gorm:"type:text" json:"title"`
+	State          string     `gorm:"index;not null" json:"state"`
+	Draft          bool       `gorm:"not null;default:false" json:"draft"`
+	AuthorLogin    string     `gorm:"index" json:"author_login"`
+	AuthorID       int64      `json:"author_id"`
+	BaseBranch     string     `gorm:"index" json:"base_branch"`
+	HeadBranch     string     `json:"head_branch"`
+	MergeCommitSHA string     `json:"merge_commit_sha"`
+	Labels         []string   `gorm:"serializer:json" json:"labels"`
+	Additions      int        `json:"additions"`
+	Deletions      int        `json:"deletions"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime:false" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime:false;index" json:"updated_at"`
+	MergedAt       *time.Time `gorm:"index" json:"merged_at"`
+	ClosedAt       *time.Time `json:"closed_at"`
+	URL            string     `gorm:"type:text" json:"url"`
+	// MergeCommitID links a merged pull request to its stored merge commit, 0 until that commit is stored
+	MergeCommitID uint `gorm:"index;not null;default:0" json:"merge_commit_id"`
+}
+
+// PullRequestResponse is a pull request as returned by the pulls API. Additions
+// and Deletions are only returned for a single pull request.
+type PullRequestResponse struct {
+	Number         int        `json:"number"`
+	Title          string     `json:"title"`
+	State          string     `json:"state"`
+	Draft          bool       `json:"draft"`
+	User           GitHubUser `json:"user"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	MergedAt       *time.Time `json:"merged_at"`
+	ClosedAt       *time.Time `json:"closed_at"`
+	MergeCommitSHA string     `json:"merge_commit_sha"`
+	HTMLURL        string     `json:"html_url"`
+	Additions      int        `json:"additions"`
+	Deletions      int        `json:"deletions"`
+	Base           struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Head struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+}
+
+func (p *PullRequestResponse) ToPullRequest(repoID uint) PullRequest {
+	pr := PullRequest{
+		RepoID:      repoID,
+		Number:      p.Number,
+		Title:       p.Title,
+		State:       p.State,
+		Draft:       p.Draft,
+		AuthorLogin: p.User.Login,
+		AuthorID:    p.User.ID,
+		BaseBranch:  p.Base.Ref,
+		HeadBranch:  p.Head.Ref,
+		Labels:      make([]string, 0, len(p.Labels)),
+		Additions:   p.Additions,
+		Deletions:   p.Deletions,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		MergedAt:    p.MergedAt,
+		ClosedAt:    p.ClosedAt,
+		URL:         p.HTMLURL,
+	}
+	if p.MergedAt != nil {
+		// before the merge GitHub reports a test merge commit here
+		pr.MergeCommitSHA = p.MergeCommitSHA
+	}
+	for _, label := range p.Labels {
+		pr.Labels = append(pr.Labels, label.Name)
+	}
+	return pr
+}
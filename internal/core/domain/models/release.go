@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Tag is a git tag of a repository and the commit it points at
+type Tag struct {
+	ID     uint   `gorm:"primaryKey" json:"-"`
+	RepoID uint   `gorm:"uniqueIndex:idx_tag_name;not null" json:"-"`
+	Name   string `gorm:"uniqueIndex:idx_tag_name;not null" json:"name"`
+	SHA    string `gorm:"index;not null" json:"sha"`
+}
+
+// Release mirrors a GitHub release. Drafts have no PublishedAt.
+type Release struct {
+	ID              uint   `gorm:"primaryKey" json:"-"`
+	GitHubID        int64  `gorm:"column:github_id;uniqueIndex;not null" json:"id"`
+	RepoID          uint   `gorm:"index;not null" json:"-"`
+	TagName         string `gorm:"index;not null" json:"tag_name"`
+	Name            string `json:"name"`
+	TargetCommitish string `json:"target_commitish"`
+	// TargetSHA is the commit the release tag points at, empty until the tag is stored
+	TargetSHA   string     `gorm:"not null;default:''" json:"target_sha"`
+	Draft       bool       `gorm:"not null;default:false" json:"draft"`
+	Prerelease  bool       `gorm:"not null;default:false" json:"prerelease"`
+	Body        string     `gorm:"type:text" json:"body"`
+	AuthorLogin string     `json:"author_login"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime:false" json:"created_at"`
+	PublishedAt *time.Time `gorm:"index" json:"published_at"`
+	URL         string     `gorm:"type:text" json:"url"`
+}
+
+type TagResponse struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+func (t *TagResponse) ToTag(repoID uint) Tag {
+	return Tag{RepoID: repoID, Name: t.Name, SHA: t.Commit.SHA}
+}
+
+type ReleaseResponse struct {
+	ID              int64      `json:"id"`
+	TagName         string     `json:"tag_name"`
+	Name            string     `json:"name"`
+	TargetCommitish string     `json:"target_commitish"`
+	Draft           bool       `json:"draft"`
+	Prerelease      bool       `json:"prerelease"`
+	Body            string     `json:"body"`
+	Author          GitHubUser `json:"author"`
+	CreatedAt       time.Time  `json:"created_at"`
+	PublishedAt     *time.Time `json:"published_at"`
+	HTMLURL         string     `json:"html_url"`
+}
+
+func (r *ReleaseResponse) ToRelease(repoID uint) Release {
+	return Release{
+		GitHubID:        r.ID,
+		RepoID:          repoID,
+		TagName:         r.TagName,
+		Name:            r.Name,
+		TargetCommitish: r.TargetCommitish,
+		Draft:           r.Draft,
+		Prerelease:      r.Prerelease,
+		Body:            r.Body,
+		AuthorLogin:     r.Author.Login,
+		CreatedAt:       r.CreatedAt,
+		PublishedAt:     r.PublishedAt,
+		URL:             r.HTMLURL,
+	}
+}
@@ -2,6 +2,21 @@ package models
 
 import "time"
 
+// Repository statuses. Active, error and archived repositories are synced on
+// every monitor cycle; paused and inactive ones are not.
+const (
+	RepositoryActive = "active"
+	// RepositoryPaused is a repository whose monitoring was stopped through the API
+	RepositoryPaused = "paused"
+	// RepositoryError is a repository whose last sync failed
+	RepositoryError = "error"
+	// RepositoryArchived is a repository archived on GitHub, which gets no new commits
+	RepositoryArchived = "archived"
+	// RepositoryInactive is a repository of an enrollment that was deleted or no
+	// longer matches its filters
+	RepositoryInactive = "inactive"
+)
+
 type Repository struct {
 	ID              uint      `gorm:"primaryKey" json:"id"`
 	FullName        string    `gorm:"unique;not null" json:"full_name"`
@@ -17,6 +32,44 @@ type Repository struct {
 	UpdatedAt       time.Time `json:"updated_at"`
 	FetchedAt       time.Time `json:"fetched_at"`
 	LastCommitSHA   string    `json:"last_commit_sha"`
+	InstallationID  int64     `json:"installation_id"`
+	// EnrichCommits fetches the files and line stats of every new commit in the background
+	EnrichCommits bool   `gorm:"not null;default:false" json:"enrich_commits"`
+	DefaultBranch string `gorm:"not null;default:''" json:"default_branch"`
+	// BranchPatterns selects the branches fetched besides the default one, e.g. release/*
+	BranchPatterns []string `gorm:"serializer:json" json:"branch_patterns"`
+	// HeadSHA and HeadDate are the head watermark, the newest commit stored, which
+	// new commits are fetched from. LastCommitSHA and BackfillDate are the backfill
+	// watermark, the oldest commit stored, which older history is fetched from.
+	HeadSHA      string     `gorm:"not null;default:''" json:"head_sha"`
+	HeadDate     *time.Time `json:"head_date"`
+	BackfillDate *time.Time `json:"backfill_date"`
+	// PullsSyncedAt and IssuesSyncedAt are when the most recently updated pull request
+	// and issue stored were last updated
+	PullsSyncedAt  *time.Time `json:"pulls_synced_at"`
+	IssuesSyncedAt *time.Time `json:"issues_synced_at"`
+	// WatchersCount mirrors GitHub's legacy watchers field, which counts stars;
+	// SubscribersCount is the people watching the repository
+	SubscribersCount int      `gorm:"not null;default:0" json:"subscribers_count"`
+	Size             int      `gorm:"not null;default:0" json:"size"`
+	Topics           []string `gorm:"serializer:json" json:"topics"`
+	Archived         bool     `gorm:"not null;default:false" json:"archived"`
+	// RunsSyncedAt is the creation time workflow runs are fetched from: that of
+	// the oldest run still in progress at the last sync, or else of the newest run
+	RunsSyncedAt *time.Time `json:"runs_synced_at"`
+	Status       string     `gorm:"index;not null;default:'active'" json:"status"`
+	Fork         bool       `gorm:"not null;default:false" json:"fork"`
+	Visibility   string     `gorm:"not null;default:''" json:"visibility"`
+	// EnrollmentID is the enrollment the repository was added through, 0 when added on its own
+	EnrollmentID uint `gorm:"index;not null;default:0" json:"enrollment_id"`
+	// Parent is the repository a fork was made from. GitHub only returns it for
+	// a single repository, not in listings, and it is not stored.
+	Parent *RepositoryRef `gorm:"-" json:"parent,omitempty"`
+}
+
+// RepositoryRef names another repository
+type RepositoryRef struct {
+	FullName string `json:"full_name"`
 }
 
 func NewRepository(full_name, name, description, url, language string, forksCount, starsCount, openIssuesCount, watchersCount int, createdAt, updatedAt time.Time) *Repository {
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Review states, as GitHub reports them
+const (
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewCommented        = "COMMENTED"
+	ReviewDismissed        = "DISMISSED"
+	ReviewPending          = "PENDING"
+)
+
+// PullRequestReview is a review submitted on a pull request
+type PullRequestReview struct {
+	ID            uint       `gorm:"primaryKey" json:"-"`
+	GitHubID      int64      `gorm:"column:github_id;uniqueIndex;not null" json:"id"`
+	RepoID        uint       `gorm:"index:idx_review_pull_request;not null" json:"-"`
+	Number        int        `gorm:"index:idx_review_pull_request;not null" json:"number"`
+	ReviewerLogin string     `gorm:"index" json:"reviewer_login"`
+	ReviewerID    int64      `json:"reviewer_id"`
+	State         string     `gorm:"not null" json:"state"`
+	SubmittedAt   *time.Time `gorm:"index" json:"submitted_at"`
+}
+
+// ReviewComment is an inline comment left on the diff of a pull request
+type ReviewComment struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	GitHubID    int64     `gorm:"column:github_id;uniqueIndex;not null" json:"id"`
+	RepoID      uint      `gorm:"index:idx_review_comment_pull_request;not null" json:"-"`
+	Number      int       `gorm:"index:idx_review_comment_pull_request;not null" json:"number"`
+	ReviewID    int64     `json:"review_id"`
+	AuthorLogin string    `gorm:"index" json:"author_login"`
+	CreatedAt   time.Time `gorm:"autoCreateTime:false;index" json:"created_at"`
+}
+
+type ReviewResponse struct {
+	ID          int64      `json:"id"`
+	User        GitHubUser `json:"user"`
+	State       string     `json:"state"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+}
+
+func (r *ReviewResponse) ToReview(repoID uint, number int) PullRequestReview {
+	return PullRequestReview{
+		GitHubID:      r.ID,
+		RepoID:        repoID,
+		Number:        number,
+		ReviewerLogin: r.User.Login,
+		ReviewerID:    r.User.ID,
+		State:         r.State,
+		SubmittedAt:   r.SubmittedAt,
+	}
+}
+
+type ReviewCommentResponse struct {
+	ID                  int64      `json:"id"`
+	PullRequestReviewID int64      `json:"pull_request_review_id"`
+	User                GitHubUser `json:"user"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+func (c *ReviewCommentResponse) ToReviewComment(repoID uint, number int) ReviewComment {
+	return ReviewComment{
+		GitHubID:    c.ID,
+		RepoID:      repoID,
+		Number:      number,
+		ReviewID:    c.PullRequestReviewID,
+		AuthorLogin: c.User.Login,
+		CreatedAt:   c.CreatedAt,
+	}
+}
@@ -0,0 +1,103 @@
+package models
+
+import "time"
+
+// RepositorySnapshot records the metadata of a repository each time it is refreshed
+type RepositorySnapshot struct {
+	ID            uint      `gorm:"primaryKey" json:"-"`
+	RepoID        uint      `gorm:"index:idx_snapshot_taken;not null" json:"-"`
+	TakenAt       time.Time `gorm:"index:idx_snapshot_taken;not null" json:"taken_at"`
+	Stars         int       `json:"stars"`
+	Forks         int       `json:"forks"`
+	OpenIssues    int       `json:"open_issues"`
+	Watchers      int       `json:"watchers"`
+	Size          int       `json:"size"`
+	DefaultBranch string    `json:"default_branch"`
+	Topics        []string  `gorm:"serializer:json" json:"topics"`
+	Archived      bool      `gorm:"not null;default:false" json:"archived"`
+}
+
+// NewRepositorySnapshot takes a snapshot of repo as just fetched. Watchers are
+// the subscribers, GitHub's watchers field being the star count.
+func NewRepositorySnapshot(repoID uint, repo *Repository, takenAt time.Time) RepositorySnapshot {
+	return RepositorySnapshot{
+		RepoID:        repoID,
+		TakenAt:       takenAt,
+		Stars:         repo.StarsCount,
+		Forks:         repo.ForksCount,
+		OpenIssues:    repo.OpenIssuesCount,
+		Watchers:      repo.SubscribersCount,
+		Size:          repo.Size,
+		DefaultBranch: repo.DefaultBranch,
+		Topics:        repo.Topics,
+		Archived:      repo.Archived,
+	}
+}
+
+// ContributorWeek is what a contributor committed to the default branch in
+// the week starting on Week, from the contributors statistics
+type ContributorWeek struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	RepoID    uint      `gorm:"uniqueIndex:idx_contributor_week;not null" json:"-"`
+	Login     string    `gorm:"uniqueIndex:idx_contributor_week;not null" json:"login"`
+	AuthorID  int64     `json:"author_id"`
+	Week      time.Time `gorm:"uniqueIndex:idx_contributor_week;not null" json:"week"`
+	Commits   int       `json:"commits"`
+	Additions int       `json:"additions"`
+	Deletions int       `json:"deletions"`
+}
+
+// CommitActivityWeek is the number of commits made in the week starting on
+// Week, in total and for each day from Sunday
+type CommitActivityWeek struct {
+	ID     uint      `gorm:"primaryKey" json:"-"`
+	RepoID uint      `gorm:"uniqueIndex:idx_commit_activity_week;not null" json:"-"`
+	Week   time.Time `gorm:"uniqueIndex:idx_commit_activity_week;not null" json:"week"`
+	Total  int       `json:"total"`
+	Days   []int     `gorm:"serializer:json" json:"days"`
+}
+
+type ContributorStatsResponse struct {
+	Author *GitHubUser `json:"author"`
+	Total  int         `json:"total"`
+	Weeks  []struct {
+		Week      int64 `json:"w"`
+		Additions int   `json:"a"`
+		Deletions int   `json:"d"`
+		Commits   int   `json:"c"`
+	} `json:"weeks"`
+}
+
+// ToContributorWeeks returns the weeks the contributor was active in. Authors
+// GitHub could not link to an account have no author and are left out.
+func (c *ContributorStatsResponse) ToContributorWeeks(repoID uint) []ContributorWeek {
+	if c.Author == nil {
+		return nil
+	}
+	var weeks []ContributorWeek
+	for _, w := range c.Weeks {
+		if w.Commits == 0 && w.Additions == 0 && w.Deletions == 0 {
+			continue
+		}
+		weeks = append(weeks, ContributorWeek{
+			RepoID:    repoID,
+			Login:     c.Author.Login,
+			AuthorID:  c.Author.ID,
+			Week:      time.Unix(w.Week, 0).UTC(),
+			Commits:   w.Commits,
+			Additions: w.Additions,
+			Deletions: w.Deletions,
+		})
+	}
+	return weeks
+}
+
+type CommitActivityResponse struct {
+	Days  []int `json:"days"`
+	Total int   `json:"total"`
+	Week  int64 `json:"week"`
+}
+
+func (c *CommitActivityResponse) ToCommitActivityWeek(repoID uint) CommitActivityWeek {
+	return CommitActivityWeek{RepoID: repoID, Week: time.Unix(c.Week, 0).UTC(), Total: c.Total, Days: c.Days}
+}
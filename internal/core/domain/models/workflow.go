@@ -0,0 +1,130 @@
+package models
+
+import "time"
+
+// Workflow run statuses and conclusions, as reported by GitHub Actions
+const (
+	WorkflowCompleted = "completed"
+	WorkflowSuccess   = "success"
+	WorkflowNeutral   = "neutral"
+	WorkflowSkipped   = "skipped"
+	WorkflowCancelled = "cancelled"
+)
+
+// WorkflowRun mirrors a GitHub Actions workflow run. Its status and conclusion
+// are those of the latest attempt.
+type WorkflowRun struct {
+	ID           uint   `gorm:"primaryKey" json:"-"`
+	GitHubID     int64  `gorm:"column:github_id;uniqueIndex;not null" json:"id"`
+	RepoID       uint   `gorm:"index:idx_workflow_run_created;not null" json:"-"`
+	WorkflowID   int64  `json:"workflow_id"`
+	WorkflowName string `gorm:"index" json:"workflow_name"`
+	RunNumber    int    `json:"run_number"`
+	RunAttempt   int    `json:"run_attempt"`
+	HeadSHA      string `gorm:"index;not null" json:"head_sha"`
+	HeadBranch   string `json:"head_branch"`
+	Event        string `json:"event"`
+	Status       string `json:"status"`
+	Conclusion   string `json:"conclusion"`
+	// CreatedAt is when the run was queued; UpdatedAt is when it finished once it is completed
+	CreatedAt time.Time  `gorm:"autoCreateTime:false;index:idx_workflow_run_created" json:"created_at"`
+	StartedAt *time.Time `json:"started_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime:false" json:"updated_at"`
+	URL       string     `gorm:"type:text" json:"url"`
+	// CommitID links the run to its stored head commit, 0 until that commit is stored
+	CommitID uint          `gorm:"index;not null;default:0" json:"commit_id"`
+	Jobs     []WorkflowJob `gorm:"foreignKey:RunID;references:GitHubID" json:"jobs,omitempty"`
+}
+
+// CompletedAt is when a completed run finished, nil while it is running
+func (r *WorkflowRun) CompletedAt() *time.Time {
+	if r.Status != WorkflowCompleted {
+		return nil
+	}
+	return &r.UpdatedAt
+}
+
+// WorkflowJob is a job of a workflow run attempt
+type WorkflowJob struct {
+	ID          uint       `gorm:"primaryKey" json:"-"`
+	GitHubID    int64      `gorm:"column:github_id;uniqueIndex;not null" json:"id"`
+	RunID       int64      `gorm:"index;not null" json:"run_id"`
+	RepoID      uint       `gorm:"index;not null" json:"-"`
+	Name        string     `json:"name"`
+	RunAttempt  int        `json:"run_attempt"`
+	HeadSHA     string     `json:"head_sha"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+type WorkflowRunResponse struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	WorkflowID   int64      `json:"workflow_id"`
+	RunNumber    int        `json:"run_number"`
+	RunAttempt   int        `json:"run_attempt"`
+	HeadSHA      string     `json:"head_sha"`
+	HeadBranch   string     `json:"head_branch"`
+	Event        string     `json:"event"`
+	Status       string     `json:"status"`
+	Conclusion   *string    `json:"conclusion"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	RunStartedAt *time.Time `json:"run_started_at"`
+	HTMLURL      string     `json:"html_url"`
+}
+
+func (r *WorkflowRunResponse) ToWorkflowRun(repoID uint) WorkflowRun {
+	run := WorkflowRun{
+		GitHubID:     r.ID,
+		RepoID:       repoID,
+		WorkflowID:   r.WorkflowID,
+		WorkflowName: r.Name,
+		RunNumber:    r.RunNumber,
+		RunAttempt:   r.RunAttempt,
+		HeadSHA:      r.HeadSHA,
+		HeadBranch:   r.HeadBranch,
+		Event:        r.Event,
+		Status:       r.Status,
+		CreatedAt:    r.CreatedAt,
+		StartedAt:    r.RunStartedAt,
+		UpdatedAt:    r.UpdatedAt,
+		URL:          r.HTMLURL,
+	}
+	if r.Conclusion != nil {
+		run.Conclusion = *r.Conclusion
+	}
+	return run
+}
+
+type WorkflowJobResponse struct {
+	ID          int64      `json:"id"`
+	RunID       int64      `json:"run_id"`
+	Name        string     `json:"name"`
+	RunAttempt  int        `json:"run_attempt"`
+	HeadSHA     string     `json:"head_sha"`
+	Status      string     `json:"status"`
+	Conclusion  *string    `json:"conclusion"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+func (j *WorkflowJobResponse) ToWorkflowJob(repoID uint) WorkflowJob {
+	job := WorkflowJob{
+		GitHubID:    j.ID,
+		RunID:       j.RunID,
+		RepoID:      repoID,
+		Name:        j.Name,
+		RunAttempt:  j.RunAttempt,
+		HeadSHA:     j.HeadSHA,
+		Status:      j.Status,
+		StartedAt:   j.StartedAt,
+		CompletedAt: j.CompletedAt,
+	}
+	if j.Conclusion != nil {
+		job.Conclusion = *j.Conclusion
+	}
+	return job
+}
@@ -1,6 +1,10 @@
 package types
 
-import "github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+import (
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+)
 
 type Pagination struct {
 	Page     int
@@ -9,7 +13,11 @@ type Pagination struct {
 
 type AuthorCommitsCount struct {
 	Author      string `json:"author"`
+	Email       string `json:"email"`
+	IdentityID  uint   `json:"identity_id"`
 	CommitCount int    `json:"commit_count"`
+	// Aliases lists every "Name <email>" the identity committed under
+	Aliases []string `gorm:"-" json:"aliases"`
 }
 
 type AuthorCommitsCountResponse struct {
@@ -17,10 +25,300 @@ type AuthorCommitsCountResponse struct {
 	Pagination PaginationResponse   `json:"pagination"`
 }
 
+type TopCommitAuthorsRequest struct {
+	// IncludeCoAuthors also credits people named in Co-authored-by trailers
+	IncludeCoAuthors bool `form:"include_co_authors"`
+	PaginationRequest
+}
+
+// AliasRuleRequest maps a commit email, optionally only together with a name,
+// to the canonical name and/or email of a person
+type AliasRuleRequest struct {
+	MatchName      string `json:"match_name"`
+	MatchEmail     string `json:"match_email" binding:"required"`
+	CanonicalName  string `json:"canonical_name"`
+	CanonicalEmail string `json:"canonical_email"`
+}
+
+// BranchPatternsRequest selects the branches fetched besides the default one,
+// by name or glob pattern such as release/*
+type BranchPatternsRequest struct {
+	Patterns []string `json:"patterns"`
+}
+
+type BranchesResponse struct {
+	DefaultBranch string                    `json:"default_branch"`
+	Patterns      []string                  `json:"patterns"`
+	Branches      []models.RepositoryBranch `json:"branches"`
+}
+
+type HistoryRewritesRequest struct {
+	Repo      string `form:"repo" binding:"required"`
+	Protected *bool  `form:"protected"`
+	PaginationRequest
+}
+
+// ListRepositoriesRequest optionally narrows a repository listing to a status
+type ListRepositoriesRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=active paused error archived inactive"`
+}
+
+type RepositoryResponse struct {
+	*models.Repository
+	Branches []models.RepositoryBranch `json:"branches"`
+}
+
+// PullRequestFilter narrows pull request listings; empty fields are not filtered on
+type PullRequestFilter struct {
+	State  string `form:"state" binding:"omitempty,oneof=open closed"`
+	Merged *bool  `form:"merged"`
+	Author string `form:"author"`
+	Base   string `form:"base"`
+	Label  string `form:"label"`
+}
+
+type FetchPullRequestsRequest struct {
+	RepoName string `form:"repo_name"`
+	PullRequestFilter
+	PaginationRequest
+}
+
+type FetchPullRequestsResponse struct {
+	PullRequests []*models.PullRequest `json:"pull_requests"`
+	Pagination   PaginationResponse    `json:"pagination"`
+}
+
+// IssueFilter narrows issue listings; empty fields are not filtered on
+type IssueFilter struct {
+	State     string `form:"state" binding:"omitempty,oneof=open closed"`
+	Author    string `form:"author"`
+	Assignee  string `form:"assignee"`
+	Label     string `form:"label"`
+	Milestone string `form:"milestone"`
+}
+
+type FetchIssuesRequest struct {
+	RepoName string `form:"repo_name"`
+	IssueFilter
+	PaginationRequest
+}
+
+type FetchIssuesResponse struct {
+	Issues     []*models.Issue    `json:"issues"`
+	Pagination PaginationResponse `json:"pagination"`
+}
+
+// ReferencingCommit is a commit mentioning an issue; Closes is set when it used a closing keyword
+type ReferencingCommit struct {
+	*models.Commit
+	Closes bool `json:"closes"`
+}
+
+type IssueResponse struct {
+	*models.Issue
+	Commits []ReferencingCommit `json:"commits"`
+}
+
+type FetchReleasesRequest struct {
+	RepoName string `form:"repo_name"`
+	PaginationRequest
+}
+
+type FetchReleasesResponse struct {
+	Releases   []*models.Release  `json:"releases"`
+	Pagination PaginationResponse `json:"pagination"`
+}
+
+// ReleaseReportRequest selects the releases to report on. Tag defaults to the
+// latest release and Base to the release published before it.
+type ReleaseReportRequest struct {
+	RepoName string `form:"repo_name"`
+	Tag      string `form:"tag"`
+	Base     string `form:"base"`
+}
+
+// ReleaseReport lists the commits made since the previous release and who
+// authored them. Commits in the range that are not stored yet are only listed
+// in UnstoredSHAs.
+type ReleaseReport struct {
+	Release      *models.Release      `json:"release"`
+	Previous     *models.Release      `json:"previous"`
+	Commits      []*models.Commit     `json:"commits"`
+	Authors      []AuthorCommitsCount `json:"authors"`
+	UnstoredSHAs []string             `json:"unstored_shas"`
+}
+
+type RepositoryHistoryRequest struct {
+	From string `form:"from"`
+	To   string `form:"to"`
+}
+
+// ContributorActivity is what a contributor committed over a date range, from the contributors statistics
+type ContributorActivity struct {
+	Login       string `json:"login"`
+	AuthorID    int64  `json:"author_id"`
+	Commits     int    `json:"commits"`
+	Additions   int    `json:"additions"`
+	Deletions   int    `json:"deletions"`
+	ActiveWeeks int    `json:"active_weeks"`
+}
+
+type RepositoryHistoryResponse struct {
+	DateWindow
+	Snapshots      []models.RepositorySnapshot `json:"snapshots"`
+	CommitActivity []models.CommitActivityWeek `json:"commit_activity"`
+	Contributors   []ContributorActivity       `json:"contributors"`
+}
+
+// CI states of a commit
+const (
+	CIStatePending = "pending"
+	CIStateFailure = "failure"
+	CIStateSuccess = "success"
+	CIStateNone    = "none"
+)
+
+// CommitCIStatus is the combined outcome of the workflow runs of a commit:
+// pending while any run is in progress, failure when any finished
+// unsuccessfully, success when all passed, and none without runs
+type CommitCIStatus struct {
+	SHA   string               `json:"sha"`
+	State string               `json:"state"`
+	Runs  []models.WorkflowRun `json:"runs"`
+}
+
+type WorkflowMetricsRequest struct {
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Workflow string `form:"workflow"`
+	// Percentiles is a comma separated list such as 50,90
+	Percentiles string `form:"percentiles"`
+}
+
+// WorkflowStats summarises workflow runs. SuccessRate is over the completed
+// runs that were neither cancelled nor skipped; Duration over completed runs.
+type WorkflowStats struct {
+	Runs        int           `json:"runs"`
+	Completed   int           `json:"completed"`
+	Successes   int           `json:"successes"`
+	SuccessRate float64       `json:"success_rate"`
+	Duration    DurationStats `json:"duration"`
+}
+
+type WorkflowWeek struct {
+	Week time.Time `json:"week"`
+	WorkflowStats
+}
+
+type WorkflowTrend struct {
+	Workflow string `json:"workflow"`
+	WorkflowStats
+	Weeks []WorkflowWeek `json:"weeks"`
+}
+
+type WorkflowMetricsResponse struct {
+	Repo      string          `json:"repo"`
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Workflows []WorkflowTrend `json:"workflows"`
+}
+
+// EnrollmentRequest enrolls the repositories of an organization or user.
+// Archived repositories and forks are left out unless included.
+type EnrollmentRequest struct {
+	Owner           string `json:"owner" form:"owner" binding:"required"`
+	IncludeArchived bool   `json:"include_archived" form:"include_archived"`
+	IncludeForks    bool   `json:"include_forks" form:"include_forks"`
+	Visibility      string `json:"visibility" form:"visibility" binding:"omitempty,oneof=all public private internal"`
+	Language        string `json:"language" form:"language"`
+	Topic           string `json:"topic" form:"topic"`
+	NameRegex       string `json:"name_regex" form:"name_regex"`
+}
+
+func (r EnrollmentRequest) ToEnrollment() *models.Enrollment {
+	visibility := r.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityAll
+	}
+	return &models.Enrollment{
+		Owner:           r.Owner,
+		IncludeArchived: r.IncludeArchived,
+		IncludeForks:    r.IncludeForks,
+		Visibility:      visibility,
+		Language:        r.Language,
+		Topic:           r.Topic,
+		NameRegex:       r.NameRegex,
+	}
+}
+
+// EnrollmentSyncResult is what a sync of an enrollment changed
+type EnrollmentSyncResult struct {
+	Owner   string `json:"owner"`
+	Listed  int    `json:"listed"`
+	Matched int    `json:"matched"`
+	// Added are the repositories stored by this sync, Reactivated those matching
+	// again and Deactivated those deleted upstream or no longer matching
+	Added       []string `json:"added"`
+	Reactivated []string `json:"reactivated"`
+	Deactivated []string `json:"deactivated"`
+}
+
+type ReviewMetricsRequest struct {
+	From string `form:"from"`
+	To   string `form:"to"`
+	// Percentiles is a comma separated list such as 50,90
+	Percentiles string `form:"percentiles"`
+}
+
+// PullRequestTiming is when a pull request was opened, first reviewed by
+// someone other than its author, first approved and merged
+type PullRequestTiming struct {
+	Number        int
+	CreatedAt     time.Time
+	FirstReviewAt *time.Time
+	ApprovedAt    *time.Time
+	MergedAt      *time.Time
+}
+
+// DurationStats summarises durations in hours by percentile, keyed p50, p90 and so on
+type DurationStats struct {
+	Samples int                `json:"samples"`
+	Hours   map[string]float64 `json:"hours"`
+}
+
+type ReviewerLoad struct {
+	Reviewer         string `json:"reviewer"`
+	PullRequests     int    `json:"pull_requests"`
+	Reviews          int    `json:"reviews"`
+	Approvals        int    `json:"approvals"`
+	ChangesRequested int    `json:"changes_requested"`
+	Comments         int    `json:"comments"`
+}
+
+type ReviewMetricsResponse struct {
+	Repo              string         `json:"repo"`
+	From              time.Time      `json:"from"`
+	To                time.Time      `json:"to"`
+	PullRequests      int            `json:"pull_requests"`
+	TimeToFirstReview DurationStats  `json:"time_to_first_review"`
+	TimeToApprove     DurationStats  `json:"time_to_approve"`
+	TimeToMerge       DurationStats  `json:"time_to_merge"`
+	Reviewers         []ReviewerLoad `json:"reviewers"`
+}
+
 type FetchCommitsByRepoNameRequest struct {
 	RepoName string `form:"repo_name"`
+	CommitFilter
 	PaginationRequest
 }
+
+// CommitFilter narrows commit listings; nil fields are not filtered on
+type CommitFilter struct {
+	Merge    *bool  `form:"merge"`
+	Verified *bool  `form:"verified"`
+	Orphaned *bool  `form:"orphaned"`
+	Branch   string `form:"branch"`
+}
 type FetchCommitsByRepoNameResponse struct {
 	Commits    []*models.Commit   `json:"commits"`
 	Pagination PaginationResponse `json:"pagination"`
@@ -41,3 +339,67 @@ type ApiError struct {
 	DocumentationURL string `json:"documentation_url"`
 	Status           string `json:"status"`
 }
+
+// RateLimitStatus is the quota GitHub last reported for one resource (core, search, graphql...)
+type RateLimitStatus struct {
+	Token        string    `json:"token,omitempty"`
+	Resource     string    `json:"resource"`
+	Limit        int       `json:"limit"`
+	Remaining    int       `json:"remaining"`
+	Used         int       `json:"used"`
+	Reset        time.Time `json:"reset"`
+	BlockedUntil time.Time `json:"blocked_until,omitempty"`
+}
+
+// TokenStatus reports the health of one configured GitHub credential
+type TokenStatus struct {
+	ID          string    `json:"id"`
+	Limit       int       `json:"limit"`
+	Remaining   int       `json:"remaining"`
+	Reset       time.Time `json:"reset"`
+	ParkedUntil time.Time `json:"parked_until,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitempty"`
+	Revoked     bool      `json:"revoked"`
+}
+
+// RetryStats counts retried GitHub requests since startup
+type RetryStats struct {
+	Retries     int64     `json:"retries"`
+	Exhausted   int64     `json:"exhausted"`
+	LastURL     string    `json:"last_url,omitempty"`
+	LastReason  string    `json:"last_reason,omitempty"`
+	LastRetryAt time.Time `json:"last_retry_at,omitempty"`
+}
+
+type DateWindow struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+const (
+	BackfillPending = "pending"
+	BackfillRunning = "running"
+	BackfillDone    = "done"
+	BackfillFailed  = "failed"
+)
+
+// BackfillWindowProgress tracks one time window of a parallel backfill
+type BackfillWindowProgress struct {
+	DateWindow
+	Status  string `json:"status"`
+	Pages   int    `json:"pages"`
+	Commits int    `json:"commits"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BackfillProgress reports a parallel backfill of one repository
+type BackfillProgress struct {
+	Repo       string                   `json:"repo"`
+	Status     string                   `json:"status"`
+	StartedAt  time.Time                `json:"started_at"`
+	FinishedAt time.Time                `json:"finished_at,omitempty"`
+	Commits    int                      `json:"commits"`
+	Duplicates int                      `json:"duplicates"`
+	Windows    []BackfillWindowProgress `json:"windows"`
+}
@@ -0,0 +1,26 @@
+package ports
+
+import (
+	"errors"
+	"time"
+)
+
+// Sentinels for errors.Is. Every error the GithubService returns for a failed
+// GitHub response matches one of them.
+var (
+	ErrNotFound     = errors.New("github: not found")
+	ErrUnauthorized = errors.New("github: unauthorized")
+	ErrForbidden    = errors.New("github: forbidden")
+	ErrRateLimited  = errors.New("github: rate limited")
+	ErrUpstream     = errors.New("github: upstream error")
+	ErrDecode       = errors.New("github: invalid response body")
+	// ErrComputing is returned when GitHub is still computing the statistics asked for
+	ErrComputing = errors.New("github: statistics are being computed")
+)
+
+// RateLimitedError is implemented by the errors matching ErrRateLimited
+type RateLimitedError interface {
+	error
+	// RetryAfter is how long callers should wait before trying again
+	RetryAfter() time.Duration
+}
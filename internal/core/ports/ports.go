@@ -1,6 +1,8 @@
 package ports
 
 import (
+	"time"
+
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
 )
@@ -8,12 +10,34 @@ import (
 type Commit interface {
 	Create(commit *models.Commit) error
 	FindByHash(hash string) (*models.Commit, error)
-	FindByRepoId(repoId uint, page int, pageSize int) ([]*models.Commit, error)
+	FindByHashes(repoID uint, hashes []string) ([]*models.Commit, error)
+	FindByRepoId(repoId uint, filter types.CommitFilter, page int, pageSize int) ([]*models.Commit, error)
 	FindAll() ([]*models.Commit, error)
 	CreateMany(commits []models.Commit) error
 	Count() (int64, error)
-	GetTopCommitAuthors(page int, pageSize int) ([]types.AuthorCommitsCount, error)
+	GetTopCommitAuthors(page int, pageSize int, includeCoAuthors bool) ([]types.AuthorCommitsCount, error)
+	// GetCommitAuthors counts the commits of every author and co-author among commitIDs, most commits first
+	GetCommitAuthors(commitIDs []uint) ([]types.AuthorCommitsCount, error)
 	UpsertCommits(commits []models.Commit) error
+	// UpsertPage stores one page fetched from branch, the default one when empty, records
+	// the commits as members of it and moves its backfill cursor in the same transaction
+	UpsertPage(repoID uint, branch string, commits []models.Commit, cursor string) error
+	// AdvanceHead moves the head watermark of a branch forward to head
+	AdvanceHead(repoID uint, branch string, head models.Commit) error
+	// FindNewest returns the newest stored commit of a branch, the whole repository for the default one
+	FindNewest(repoID uint, branch string) (*models.Commit, error)
+	// FindUnenriched returns up to limit commits of a repository whose files were not fetched yet, newest first
+	FindUnenriched(repoID uint, limit int) ([]models.Commit, error)
+	// SaveEnrichment stores the line stats and files of a commit and marks it enriched
+	SaveEnrichment(commit *models.Commit, files []models.CommitFile) error
+	// Reprocess re-derives trailer participants, issue references and author identities of every stored commit and returns how many were processed
+	Reprocess(batchSize int) (int, error)
+	// RecordRewrite stores a detected history rewrite, marks the dropped commits
+	// orphaned and clears the branch's resume cursor in the same transaction
+	RecordRewrite(rewrite *models.HistoryRewrite, dropped []string) error
+	// FindRewrites returns a page of rewrites, newest first, followed by the first
+	// rewrite of the next page, if any
+	FindRewrites(repoID uint, protected *bool, page int, pageSize int) ([]models.HistoryRewrite, error)
 }
 
 type Repository interface {
@@ -21,9 +45,174 @@ type Repository interface {
 	FindByName(name string) (*models.Repository, error)
 	FindAll() ([]*models.Repository, error)
 	UpdateLastCommitSHA(id uint, sha string) error
+	UpdateInstallationID(id uint, installationID int64) error
+	UpdateEnrichCommits(id uint, enabled bool) error
+	UpdateDefaultBranch(id uint, branch string) error
+	UpdateBranchPatterns(id uint, patterns []string) error
+	// FindBranches returns the resume cursors of the non-default branches fetched so far
+	FindBranches(id uint) ([]models.RepositoryBranch, error)
+	UpdatePullsSyncedAt(id uint, syncedAt time.Time) error
+	UpdateIssuesSyncedAt(id uint, syncedAt time.Time) error
+	UpdateRunsSyncedAt(id uint, syncedAt time.Time) error
+	FindByEnrollment(enrollmentID uint) ([]*models.Repository, error)
+	UpdateStatus(id uint, status string) error
+	// ResetCursors clears the watermarks of the repository and its branches, so
+	// their commits are imported again at the next sync
+	ResetCursors(id uint) error
+	// Delete removes a repository with its commits and everything else stored for it
+	Delete(id uint) error
+	UpdateEnrollment(id uint, enrollmentID uint) error
+	// RecordSnapshot refreshes the stored metadata of a repository and appends a snapshot of it
+	RecordSnapshot(id uint, meta *models.Repository, takenAt time.Time) error
+	FindSnapshots(id uint, window types.DateWindow) ([]models.RepositorySnapshot, error)
+}
+
+type PullRequest interface {
+	// UpsertPullRequests stores pull requests and links merged ones to their stored merge commits
+	UpsertPullRequests(prs []models.PullRequest) error
+	FindByNumber(repoID uint, number int) (*models.PullRequest, error)
+	// FindByRepoId returns a page of pull requests, newest first, followed by the
+	// first one of the next page, if any
+	FindByRepoId(repoID uint, filter types.PullRequestFilter, page int, pageSize int) ([]*models.PullRequest, error)
+	// SaveReviews replaces the reviews and review comments stored for a pull request
+	SaveReviews(repoID uint, number int, reviews []models.PullRequestReview, comments []models.ReviewComment) error
+	// FindReviewTimings returns when each pull request created in window was first reviewed, approved and merged
+	FindReviewTimings(repoID uint, window types.DateWindow) ([]types.PullRequestTiming, error)
+	// GetReviewLoad counts the reviews and review comments of every reviewer submitted in window, busiest first
+	GetReviewLoad(repoID uint, window types.DateWindow) ([]types.ReviewerLoad, error)
+}
+
+type Identity interface {
+	// FindAll returns a page of identities followed by the first identity of the
+	// next page, if any, so callers can tell whether there is one
+	FindAll(page int, pageSize int) ([]*models.Identity, error)
+	FindRules() ([]models.AliasRule, error)
+	CreateRule(rule *models.AliasRule) error
+	DeleteRule(id uint) error
+	// ReplaceRules swaps every alias rule from source, such as one .mailmap file, for rules
+	ReplaceRules(source string, rules []models.AliasRule) error
+	// Rebuild resolves the identities of all commits from scratch, after the alias rules changed
+	Rebuild(batchSize int) (int, error)
+}
+
+type Issue interface {
+	UpsertIssues(issues []models.Issue) error
+	FindByNumber(repoID uint, number int) (*models.Issue, error)
+	// FindByRepoId returns a page of issues, newest first, followed by the first
+	// one of the next page, if any
+	FindByRepoId(repoID uint, filter types.IssueFilter, page int, pageSize int) ([]*models.Issue, error)
+	// FindReferencingCommits returns the stored commits whose message mentions an issue
+	FindReferencingCommits(repoID uint, number int) ([]types.ReferencingCommit, error)
+}
+
+type Release interface {
+	// SaveTags and SaveReleases replace the stored tags and releases of a repository
+	SaveTags(repoID uint, tags []models.Tag) error
+	SaveReleases(repoID uint, releases []models.Release) error
+	// FindReleases returns a page of releases, latest published first, followed by
+	// the first one of the next page, if any
+	FindReleases(repoID uint, page int, pageSize int) ([]*models.Release, error)
+	FindByTag(repoID uint, tag string) (*models.Release, error)
+	// FindLatest returns the most recently published release that is neither a draft nor a prerelease
+	FindLatest(repoID uint) (*models.Release, error)
+	// FindPrevious returns the release published last before release, skipping prereleases unless it is one
+	FindPrevious(release *models.Release) (*models.Release, error)
 }
 
+type Stats interface {
+	// SaveContributorStats replaces the contributor weeks of a repository
+	SaveContributorStats(repoID uint, weeks []models.ContributorWeek) error
+	// SaveCommitActivity upserts commit activity weeks, keeping the older ones
+	SaveCommitActivity(repoID uint, weeks []models.CommitActivityWeek) error
+	FindCommitActivity(repoID uint, window types.DateWindow) ([]models.CommitActivityWeek, error)
+	// FindContributorActivity sums the contributor weeks in window by contributor, most commits first
+	FindContributorActivity(repoID uint, window types.DateWindow) ([]types.ContributorActivity, error)
+}
+
+type Workflow interface {
+	// UpsertRuns stores workflow runs and links them to their stored head commits
+	UpsertRuns(runs []models.WorkflowRun) error
+	// SaveJobs replaces the stored jobs of a workflow run
+	SaveJobs(runID int64, jobs []models.WorkflowJob) error
+	FindRunsByCommit(repoID uint, sha string) ([]models.WorkflowRun, error)
+	FindRuns(repoID uint, window types.DateWindow, workflow string) ([]models.WorkflowRun, error)
+}
+
+type Enrollment interface {
+	// Save creates the enrollment of an owner or replaces the filters of the existing one
+	Save(enrollment *models.Enrollment) error
+	FindAll() ([]models.Enrollment, error)
+	FindByOwner(owner string) (*models.Enrollment, error)
+	// Delete removes an enrollment, keeping its repositories
+	Delete(owner string) error
+	MarkSynced(id uint, isOrg bool, syncedAt time.Time) error
+}
+
+type HTTPCache interface {
+	Get(url string) (*models.HTTPCacheEntry, error)
+	Save(entry *models.HTTPCacheEntry) error
+}
+
+// CommitPageHandler receives each page of commits as it is fetched, with the SHA
+// to resume from once the page is stored. Returning an error stops the fetch.
+type CommitPageHandler func(commits []models.Commit, cursor string) error
+
+// PullRequestPageHandler receives each page of pull requests as it is fetched.
+// Returning an error stops the fetch.
+type PullRequestPageHandler func(prs []models.PullRequest) error
+
+// IssuePageHandler receives each page of issues as it is fetched. Returning an
+// error stops the fetch.
+type IssuePageHandler func(issues []models.Issue) error
+
+// WorkflowRunPageHandler receives each page of workflow runs as it is fetched.
+// Returning an error stops the fetch.
+type WorkflowRunPageHandler func(runs []models.WorkflowRun) error
+
+// TagListHandler receives every tag of a repository at once. It is not called
+// when the tags are unchanged since they were last handled.
+type TagListHandler func(tags []models.Tag) error
+
+// ReleaseListHandler receives every release of a repository at once. It is not
+// called when the releases are unchanged since they were last handled.
+type ReleaseListHandler func(releases []models.Release) error
+
 type GithubService interface {
 	FetchRepository(repoName string) (*models.Repository, error)
-	FetchCommits(repoName string, repoID uint, config models.CommitConfig) ([]models.Commit, string, int, error)
+	FetchCommits(repoName string, repoID uint, config models.CommitConfig, handle CommitPageHandler) error
+	// FetchCommitDetail fetches a single commit with its stats and changed files
+	FetchCommitDetail(repoName string, sha string) (*models.CommitResponse, error)
+	// FetchFile returns the content of a file on the default branch
+	FetchFile(repoName string, path string) ([]byte, error)
+	// ListBranches returns the names of every branch of a repository
+	ListBranches(repoName string) ([]string, error)
+	// FetchBranch returns the head commit and protection of a branch
+	FetchBranch(repoName string, branch string) (*models.BranchResponse, error)
+	// CompareCommits compares two commits or refs, listing the commits reachable from head only
+	CompareCommits(repoName string, base string, head string) (*models.CompareResponse, error)
+	// CompareStatus compares two commits or refs in one request, without listing their commits
+	CompareStatus(repoName string, base string, head string) (*models.CompareResponse, error)
+	// FetchPullRequests pages through the pull requests updated since since, all of them when nil
+	FetchPullRequests(repoName string, repoID uint, since *time.Time, handle PullRequestPageHandler) error
+	// FetchPullRequest fetches a single pull request with its line stats
+	FetchPullRequest(repoName string, number int) (*models.PullRequestResponse, error)
+	FetchPullRequestReviews(repoName string, repoID uint, number int) ([]models.PullRequestReview, error)
+	// ListOwnerRepositories lists the repositories of an organization or user and reports whether it is an organization
+	ListOwnerRepositories(owner string) ([]models.Repository, bool, error)
+	FetchTags(repoName string, repoID uint, handle TagListHandler) error
+	// FetchWorkflowRuns pages through the workflow runs created since since, all of them when nil
+	FetchWorkflowRuns(repoName string, repoID uint, since *time.Time, handle WorkflowRunPageHandler) error
+	FetchWorkflowJobs(repoName string, repoID uint, runID int64) ([]models.WorkflowJob, error)
+	// FetchContributorStats and FetchCommitActivity read GitHub's statistics,
+	// returning an error matching ErrComputing while they are being computed
+	FetchContributorStats(repoName string, repoID uint) ([]models.ContributorWeek, error)
+	FetchCommitActivity(repoName string, repoID uint) ([]models.CommitActivityWeek, error)
+	FetchReleases(repoName string, repoID uint, handle ReleaseListHandler) error
+	// FetchIssues pages through the issues updated since since, all of them when nil, leaving out pull requests
+	FetchIssues(repoName string, repoID uint, since *time.Time, handle IssuePageHandler) error
+	// FetchReviewComments returns the inline comments left on the diff of a pull request
+	FetchReviewComments(repoName string, repoID uint, number int) ([]models.ReviewComment, error)
+	RateLimitStatus() []types.RateLimitStatus
+	TokenStatus() []types.TokenStatus
+	RetryStats() types.RetryStats
 }
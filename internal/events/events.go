@@ -14,6 +14,22 @@ type AddCommitEvent struct {
 type StartMonitorEvent struct {
 }
 
+// EnrichCommitsEvent asks for the files and line stats of a repository's new commits
+type EnrichCommitsEvent struct {
+	Repo *models.Repository
+}
+
+// RebuildIdentitiesEvent re-resolves every commit author after the alias rules changed
+type RebuildIdentitiesEvent struct {
+	Reason string
+}
+
+// HistoryRewrittenEvent reports a branch whose history was rewritten, e.g. by a force push
+type HistoryRewrittenEvent struct {
+	Repo    *models.Repository
+	Rewrite models.HistoryRewrite
+}
+
 func (e AddCommitEvent) EventType() string {
 	return "AddCommitEvent"
 }
@@ -21,3 +37,15 @@ func (e AddCommitEvent) EventType() string {
 func (e StartMonitorEvent) EventType() string {
 	return "StartMonitorEvent"
 }
+
+func (e EnrichCommitsEvent) EventType() string {
+	return "EnrichCommitsEvent"
+}
+
+func (e RebuildIdentitiesEvent) EventType() string {
+	return "RebuildIdentitiesEvent"
+}
+
+func (e HistoryRewrittenEvent) EventType() string {
+	return "HistoryRewrittenEvent"
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+)
+
+// ParseEnrollmentSpecs reads a semicolon separated list of owners to enroll,
+// each optionally followed by its filters as a query string, e.g.
+// "my-org?language=Go&include_forks=true;other-user"
+func ParseEnrollmentSpecs(spec string) ([]types.EnrollmentRequest, error) {
+	var requests []types.EnrollmentRequest
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		owner, query, _ := strings.Cut(entry, "?")
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filters for %s: %w", owner, err)
+		}
+		req := types.EnrollmentRequest{Owner: strings.TrimSpace(owner)}
+		for key := range values {
+			value := values.Get(key)
+			switch key {
+			case "include_archived":
+				req.IncludeArchived, err = strconv.ParseBool(value)
+			case "include_forks":
+				req.IncludeForks, err = strconv.ParseBool(value)
+			case "visibility":
+				req.Visibility = value
+			case "language":
+				req.Language = value
+			case "topic":
+				req.Topic = value
+			case "name_regex":
+				req.NameRegex = value
+			default:
+				err = fmt.Errorf("unknown filter %q", key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid filters for %s: %w", owner, err)
+			}
+		}
+		if err := ValidateEnrollment(req); err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// ValidateEnrollment checks the owner, visibility and name regex of an enrollment request
+func ValidateEnrollment(req types.EnrollmentRequest) error {
+	if req.Owner == "" || strings.Contains(req.Owner, "/") {
+		return fmt.Errorf("invalid owner %q", req.Owner)
+	}
+	switch req.Visibility {
+	case "", models.VisibilityAll, models.VisibilityPublic, models.VisibilityPrivate, models.VisibilityInternal:
+	default:
+		return fmt.Errorf("invalid visibility %q for %s", req.Visibility, req.Owner)
+	}
+	if req.NameRegex != "" {
+		if _, err := regexp.Compile(req.NameRegex); err != nil {
+			return fmt.Errorf("invalid name_regex for %s: %w", req.Owner, err)
+		}
+	}
+	return nil
+}
@@ -3,22 +3,23 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
 )
 
 const (
-	baseURL           = "https://api.github.com/repos/%s/commits?per_page=100"
-	authHeader        = "Authorization"
-	rateLimitErrorMsg = "rate limit exceeded"
-	tooManyRequests   = http.StatusTooManyRequests
-	successStatus     = http.StatusOK
+	commitsPath = "%s/repos/%s/commits?per_page=100"
 )
 
-func BuildGHCommitURL(repoName string, config models.CommitConfig) string {
-	url := fmt.Sprintf(baseURL, repoName)
+// HTTPDoer sends a request; *http.Client satisfies it, as does the GitHub adapter's client
+// which adds authentication and User-Agent headers.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+func BuildGHCommitURL(baseURL, repoName string, config models.CommitConfig) string {
+	url := fmt.Sprintf(commitsPath, baseURL, repoName)
 	if config.StartDate != "" {
 		url += fmt.Sprintf("&since=%s", config.StartDate)
 	}
@@ -27,47 +28,52 @@ func BuildGHCommitURL(repoName string, config models.CommitConfig) string {
 	}
 	if config.Sha != "" {
 		url += fmt.Sprintf("&sha=%s", config.Sha)
+	} else if config.Branch != "" {
+		url += fmt.Sprintf("&sha=%s", config.Branch)
 	}
 	return url
 }
 
-func FetchBatch(url, token string) ([]models.CommitResponse, string, int, error) {
-	var rL int
+// DecodeError is a commit page whose body could not be parsed
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "failed to decode commits: " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// FetchBatch fetches one page of commits. client is expected to return failed
+// responses as errors, which are passed through as they are.
+func FetchBatch(client HTTPDoer, url string) ([]models.CommitResponse, string, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, "", rL, err
-	}
-	if token != "" {
-		req.Header.Set(authHeader, "Bearer "+token)
+		return nil, "", err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, "", rL, err
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == tooManyRequests {
-		rateLimit, err := HandleRateLimit(resp)
-		if err != nil {
-			return nil, "", rateLimit, err
-		}
-		return nil, "", rL, fmt.Errorf(rateLimitErrorMsg)
-	}
-
-	if resp.StatusCode != successStatus {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, "", rL, fmt.Errorf("failed to fetch commits: %s", string(bodyBytes))
+	if resp.StatusCode == http.StatusNotModified {
+		// conditional request matched: nothing new since the cached response
+		return nil, "", nil
 	}
 
 	var commits []models.CommitResponse
 	if err := json.NewDecoder(resp.Body).Decode(&commits); err != nil {
-		return nil, "", rL, err
+		return nil, "", &DecodeError{Err: err}
 	}
 
 	linkHeader := resp.Header.Get("Link")
 	links := ParseLinkHeader(linkHeader)
 	nextURL := links["next"]
 
-	return commits, nextURL, rL, nil
+	return commits, nextURL, nil
 }
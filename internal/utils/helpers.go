@@ -3,7 +3,6 @@ package utils
 import (
 	"errors"
 	"fmt"
-	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -26,32 +25,6 @@ func InfoResponse(ctx *gin.Context, message string, data interface{}, statusCode
 	})
 }
 
-// HandleRateLimit handles GitHub rate limiting by waiting until the rate limit resets
-func HandleRateLimit(resp *http.Response) (int, error) {
-	var waitDuration int
-	retryAfter := resp.Header.Get("Retry-After")
-	if retryAfter != "" {
-		retrySeconds, err := strconv.Atoi(retryAfter)
-		if err != nil {
-			return waitDuration, err
-		}
-		waitDuration = int(time.Duration(retrySeconds) * time.Second)
-		return waitDuration, nil
-	}
-
-	rateLimitRemaining := resp.Header.Get("X-RateLimit-Remaining")
-	if rateLimitRemaining == "0" {
-		rateLimitReset := resp.Header.Get("X-RateLimit-Reset")
-		resetTime, err := strconv.ParseInt(rateLimitReset, 10, 64)
-		if err != nil {
-			return waitDuration, err
-		}
-		waitDuration := time.Until(time.Unix(resetTime, 0))
-		return int(waitDuration), nil
-	}
-	return waitDuration, errors.New("unknown rate limit issue")
-}
-
 // ParseLinkHeader parses the GitHub link header for pagination
 func ParseLinkHeader(header string) map[string]string {
 	links := make(map[string]string)
@@ -120,3 +93,59 @@ func ValidateDates(startDate, endDate string) error {
 
 	return nil
 }
+
+// SplitDateRange cuts startDate..endDate (YYYY-MM-DD) into consecutive windows of
+// at most window length. Adjacent windows share their boundary instant.
+func SplitDateRange(startDate, endDate string, window time.Duration) ([]types.DateWindow, error) {
+	const layout = "2006-01-02"
+	if window <= 0 {
+		return nil, errors.New("window must be positive")
+	}
+	start, err := time.Parse(layout, startDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date format: %v", err)
+	}
+	end, err := time.Parse(layout, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date format: %v", err)
+	}
+	if !start.Before(end) {
+		return nil, errors.New("start date must be before end date")
+	}
+
+	var windows []types.DateWindow
+	for since := start; since.Before(end); since = since.Add(window) {
+		until := since.Add(window)
+		if until.After(end) {
+			until = end
+		}
+		windows = append(windows, types.DateWindow{Since: since, Until: until})
+	}
+	return windows, nil
+}
+
+// ParseDateRange reads an optional from..to range of YYYY-MM-DD dates, both
+// inclusive. A missing to is today and a missing from is days before to.
+func ParseDateRange(from, to string, days int) (types.DateWindow, error) {
+	const layout = "2006-01-02"
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	if to != "" {
+		parsed, err := time.Parse(layout, to)
+		if err != nil {
+			return types.DateWindow{}, fmt.Errorf("invalid to date format: %v", err)
+		}
+		end = parsed
+	}
+	start := end.AddDate(0, 0, -days)
+	if from != "" {
+		parsed, err := time.Parse(layout, from)
+		if err != nil {
+			return types.DateWindow{}, fmt.Errorf("invalid from date format: %v", err)
+		}
+		start = parsed
+	}
+	if start.After(end) {
+		return types.DateWindow{}, errors.New("from date must not be after to date")
+	}
+	return types.DateWindow{Since: start, Until: end.AddDate(0, 0, 1)}, nil
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+)
+
+// mailmapEntry matches the four .mailmap forms:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+var mailmapEntry = regexp.MustCompile(`^([^<]*)<([^>]*)>\s*(?:([^<]*)<([^>]*)>)?$`)
+
+// ParseMailmap reads .mailmap content into alias rules tagged with source.
+// Comments, blank lines and malformed lines are skipped.
+func ParseMailmap(content, source string) []models.AliasRule {
+	var rules []models.AliasRule
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		match := mailmapEntry.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		properName, properEmail := strings.TrimSpace(match[1]), strings.TrimSpace(match[2])
+		rule := models.AliasRule{CanonicalName: properName, Source: source}
+		if match[4] == "" {
+			// Proper Name <commit@email>
+			rule.MatchEmail = strings.ToLower(properEmail)
+		} else {
+			rule.CanonicalEmail = strings.ToLower(properEmail)
+			rule.MatchName = strings.TrimSpace(match[3])
+			rule.MatchEmail = strings.ToLower(strings.TrimSpace(match[4]))
+		}
+		if rule.MatchEmail == "" {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ApplyAliasRules returns the canonical name and email for a commit author. A rule
+// matching both name and email wins over one matching the email alone.
+func ApplyAliasRules(rules []models.AliasRule, name, email string) (string, string) {
+	var best *models.AliasRule
+	for i := range rules {
+		rule := &rules[i]
+		if !strings.EqualFold(rule.MatchEmail, email) {
+			continue
+		}
+		if rule.MatchName != "" && !strings.EqualFold(rule.MatchName, name) {
+			continue
+		}
+		if best == nil || (best.MatchName == "" && rule.MatchName != "") {
+			best = rule
+		}
+	}
+	if best == nil {
+		return name, email
+	}
+	if best.CanonicalName != "" {
+		name = best.CanonicalName
+	}
+	if best.CanonicalEmail != "" {
+		email = best.CanonicalEmail
+	}
+	return name, email
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// issueReference matches #123 and GH-123, optionally after a closing keyword.
+// The mention must not follow a word character or a slash, which leaves out
+// owner/repo#123 and anchors in URLs.
+var issueReference = regexp.MustCompile(`(?i)(?:^|[^\w/])(?:(close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+)?(?:#|gh-)(\d+)\b`)
+
+// IssueReference is an issue or pull request mentioned in a commit message
+type IssueReference struct {
+	Number int
+	Closes bool
+}
+
+// ParseIssueReferences extracts the issues of the same repository a commit
+// message refers to, in order of first mention. A number mentioned several
+// times is returned once, closing if any of the mentions is.
+func ParseIssueReferences(message string) []IssueReference {
+	var refs []IssueReference
+	index := make(map[int]int)
+	for _, match := range issueReference.FindAllStringSubmatch(message, -1) {
+		number, err := strconv.Atoi(match[2])
+		if err != nil || number == 0 {
+			continue
+		}
+		closes := match[1] != ""
+		if i, ok := index[number]; ok {
+			refs[i].Closes = refs[i].Closes || closes
+			continue
+		}
+		index[number] = len(refs)
+		refs = append(refs, IssueReference{Number: number, Closes: closes})
+	}
+	return refs
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParsePercentiles reads a comma separated list of percentiles such as "50,90,99.9"
+func ParsePercentiles(list string) ([]float64, error) {
+	var percentiles []float64
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(part), "p"))
+		if part == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(part, 64)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("invalid percentile %q", part)
+		}
+		percentiles = append(percentiles, p)
+	}
+	return percentiles, nil
+}
+
+// PercentileKey names a percentile in responses, e.g. p50 or p99.9
+func PercentileKey(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// Percentiles returns the given percentiles of values, interpolating linearly
+// between the closest ranks. It returns nil when there are no values.
+func Percentiles(values []float64, percentiles []float64) map[string]float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	result := make(map[string]float64, len(percentiles))
+	for _, p := range percentiles {
+		rank := p / 100 * float64(len(sorted)-1)
+		lower := int(rank)
+		value := sorted[lower]
+		if lower+1 < len(sorted) {
+			value += (rank - float64(lower)) * (sorted[lower+1] - sorted[lower])
+		}
+		result[PercentileKey(p)] = value
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+)
+
+// trailerRoles maps the lower cased trailer keys we track to participant roles
+var trailerRoles = map[string]string{
+	"co-authored-by": models.RoleCoAuthor,
+	"signed-off-by":  models.RoleSigner,
+	"reviewed-by":    models.RoleReviewer,
+	"reported-by":    models.RoleReporter,
+}
+
+// trailerLine matches "Key: Name <email>"; names are taken as is since bot names
+// such as dependabot[bot] are not valid RFC 5322 display names
+var trailerLine = regexp.MustCompile(`^([A-Za-z-]+):\s*(.*?)\s*<([^<>\s]+@[^<>\s]+)>$`)
+
+// Trailer is a person named in a commit message trailer such as
+// "Co-authored-by: Ada Lovelace <ada@example.com>"
+type Trailer struct {
+	Role  string
+	Name  string
+	Email string
+}
+
+// ParseTrailers extracts the Co-authored-by, Signed-off-by, Reviewed-by and
+// Reported-by trailers of a commit message. Keys are matched case-insensitively,
+// emails are lower cased and a person named twice in one role is returned once.
+func ParseTrailers(message string) []Trailer {
+	var trailers []Trailer
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(message, "\n") {
+		match := trailerLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		role, ok := trailerRoles[strings.ToLower(match[1])]
+		if !ok {
+			continue
+		}
+		name := strings.Trim(match[2], `"`)
+		trailer := Trailer{Role: role, Name: name, Email: strings.ToLower(match[3])}
+		key := trailer.Role + " " + trailer.Email
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		trailers = append(trailers, trailer)
+	}
+	return trailers
+}
@@ -1,13 +1,22 @@
 package api_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/api"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/ports"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
@@ -19,7 +28,7 @@ func TestFetchRepository(t *testing.T) {
 	}))
 	defer mockServer.Close()
 	logger, _ := zap.NewDevelopment()
-	githubApi := api.NewGitHubAPI("", logger)
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
 	repoName := "chromium/chromium"
 	repo, err := githubApi.FetchRepository(repoName)
 	fmt.Println("repo", repo, "err", err)
@@ -28,18 +37,897 @@ func TestFetchRepository(t *testing.T) {
 	assert.Equal(t, repoName, repo.FullName)
 }
 
-func TestFetchCommits(t *testing.T) {
+// collectCommits gathers every streamed page along with the cursor reported for it
+func collectCommits(githubApi ports.GithubService, repoName string, config models.CommitConfig) ([]models.Commit, []string, error) {
+	commits := []models.Commit{}
+	var cursors []string
+	err := githubApi.FetchCommits(repoName, 1, config, func(page []models.Commit, cursor string) error {
+		commits = append(commits, page...)
+		cursors = append(cursors, cursor)
+		return nil
+	})
+	return commits, cursors, err
+}
 
+func TestFetchCommits(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/chromium/chromium/commits", r.URL.Path)
+		assert.Equal(t, "2023-01-01", r.URL.Query().Get("since"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[{"sha": "abc123", "commit": {"author": {"name": "tobi"}, "committer": {"name": "GitHub", "email": "noreply@github.com"},
+			"message": "Merge pull request #1", "comment_count": 2, "verification": {"verified": true, "reason": "valid"}},
+			"author": {"login": "tobi", "id": 7}, "committer": {"login": "web-flow", "id": 19864447},
+			"parents": [{"sha": "p1"}, {"sha": "p2"}]}]`))
+	}))
+	defer mockServer.Close()
 	logger, _ := zap.NewDevelopment()
-	githubApi := api.NewGitHubAPI("", logger)
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
 	config := models.CommitConfig{
 		StartDate: "2023-01-01",
 		EndDate:   "2023-12-31",
 	}
 
 	repoName := "chromium/chromium"
-	commits, _, rl, err := githubApi.FetchCommits(repoName, 1, config)
+	commits, _, err := collectCommits(githubApi, repoName, config)
+	assert.NoError(t, err)
+	assert.Len(t, commits, 1)
+	assert.Equal(t, "GitHub", commits[0].CommitterName)
+	assert.Equal(t, "web-flow", commits[0].CommitterLogin)
+	assert.Equal(t, int64(7), commits[0].AuthorID)
+	assert.Equal(t, []string{"p1", "p2"}, commits[0].Parents)
+	assert.True(t, commits[0].IsMerge)
+	assert.True(t, commits[0].Verified)
+	assert.Equal(t, "valid", commits[0].VerificationReason)
+	assert.Equal(t, 2, commits[0].CommentCount)
+}
+
+func TestOptionsUserAgentAndToken(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ghes-monitor", r.Header.Get("User-Agent"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": 1, "full_name": "org/repo"}`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{
+		BaseURL:    mockServer.URL + "/",
+		HTTPClient: mockServer.Client(),
+		UserAgent:  "ghes-monitor",
+		Token:      "secret",
+	}, logger)
+	_, err := githubApi.FetchRepository("org/repo")
+	assert.NoError(t, err)
+}
+
+type memoryCache struct {
+	entries map[string]*models.HTTPCacheEntry
+}
+
+func (m *memoryCache) Get(url string) (*models.HTTPCacheEntry, error) {
+	if entry, ok := m.entries[url]; ok {
+		return entry, nil
+	}
+	return nil, fmt.Errorf("not found")
+}
+
+func (m *memoryCache) Save(entry *models.HTTPCacheEntry) error {
+	m.entries[entry.URL] = entry
+	return nil
+}
+
+func TestConditionalRequests(t *testing.T) {
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Path == "/repos/org/repo" {
+			w.Write([]byte(`{"id": 1, "full_name": "org/repo"}`))
+			return
+		}
+		w.Write([]byte(`[{"sha": "abc123", "commit": {"author": {"name": "tobi"}}}]`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	cache := &memoryCache{entries: map[string]*models.HTTPCacheEntry{}}
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL, Cache: cache}, logger)
+
+	for i := 0; i < 2; i++ {
+		repo, err := githubApi.FetchRepository("org/repo")
+		assert.NoError(t, err)
+		assert.Equal(t, "org/repo", repo.FullName)
+	}
+
+	commits, _, err := collectCommits(githubApi, "org/repo", models.CommitConfig{})
+	assert.NoError(t, err)
+	assert.Len(t, commits, 1)
+	commits, _, err = collectCommits(githubApi, "org/repo", models.CommitConfig{})
+	assert.NoError(t, err)
+	assert.Len(t, commits, 0)
+	assert.Equal(t, 4, requests)
+}
+
+func TestRateLimitWaitsForReset(t *testing.T) {
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Resource", "core")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10))
+		if requests == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Used", "5000")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Used", "1")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": 1, "full_name": "org/repo"}`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	repo, err := githubApi.FetchRepository("org/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, "org/repo", repo.FullName)
+	assert.Equal(t, 2, requests)
+
+	status := githubApi.RateLimitStatus()
+	assert.Len(t, status, 1)
+	assert.Equal(t, "core", status[0].Resource)
+	assert.Equal(t, 4999, status[0].Remaining)
+}
+
+func TestTokenRotation(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		switch r.Header.Get("Authorization") {
+		case "Bearer ghp_exhausted0001":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+		case "Bearer ghp_revoked0002":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message": "Bad credentials"}`))
+		default:
+			w.Header().Set("X-RateLimit-Remaining", "4000")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": 1, "full_name": "org/repo"}`))
+		}
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{
+		BaseURL: mockServer.URL,
+		Tokens:  []string{"ghp_exhausted0001", "ghp_revoked0002", "ghp_healthy0003"},
+	}, logger)
+
+	_, err := githubApi.FetchRepository("org/repo")
+	assert.NoError(t, err)
+
+	tokens := githubApi.TokenStatus()
+	assert.Len(t, tokens, 3)
+	assert.Equal(t, "ghp_…0001", tokens[0].ID)
+	assert.Equal(t, 0, tokens[0].Remaining)
+	assert.True(t, tokens[0].ParkedUntil.After(time.Now()))
+	assert.True(t, tokens[1].Revoked)
+	assert.False(t, tokens[2].Revoked)
+	assert.Equal(t, 4000, tokens[2].Remaining)
+}
+
+func TestAppAuthentication(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tokenRequests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/repos/org/repo/installation":
+			assert.Len(t, strings.Split(strings.TrimPrefix(auth, "Bearer "), "."), 3)
+			w.Write([]byte(`{"id": 42}`))
+		case "/app/installations/42/access_tokens":
+			tokenRequests++
+			assert.Equal(t, http.MethodPost, r.Method)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "ghs_installation", "expires_at": "%s"}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+		default:
+			assert.Equal(t, "Bearer ghs_installation", auth)
+			w.Write([]byte(`{"id": 1, "full_name": "org/repo"}`))
+		}
+	}))
+	defer mockServer.Close()
+
+	app, err := api.NewAppAuth("1234", privateKey, nil)
+	assert.NoError(t, err)
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL, App: app}, logger)
+
+	for i := 0; i < 2; i++ {
+		repo, err := githubApi.FetchRepository("org/repo")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), repo.InstallationID)
+	}
+	assert.Equal(t, 1, tokenRequests)
+}
+
+func TestAppWithoutInstallationIsNotRetried(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	lookups := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/org/repo/installation" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		lookups++
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	defer mockServer.Close()
+
+	app, err := api.NewAppAuth("1234", privateKey, nil)
+	assert.NoError(t, err)
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{
+		BaseURL: mockServer.URL,
+		App:     app,
+		Retry:   api.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	}, logger)
+
+	_, err = githubApi.FetchRepository("org/repo")
+	assert.ErrorIs(t, err, api.ErrNotFound)
+	assert.Equal(t, 1, lookups)
+	assert.Zero(t, githubApi.RetryStats().Retries)
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.URL.Path == "/repos/org/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		case requests == 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case requests == 2:
+			// promise more than is sent so the body is truncated
+			w.Header().Set("Content-Length", "100")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": 1,`))
+		default:
+			w.Write([]byte(`{"id": 1, "full_name": "org/repo"}`))
+		}
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{
+		BaseURL: mockServer.URL,
+		Retry:   api.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	}, logger)
+
+	repo, err := githubApi.FetchRepository("org/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, "org/repo", repo.FullName)
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, int64(2), githubApi.RetryStats().Retries)
+
+	_, err = githubApi.FetchRepository("org/missing")
+	assert.ErrorIs(t, err, api.ErrNotFound)
+	assert.Equal(t, 4, requests)
+}
+
+func TestTypedErrors(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/private":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "Resource not accessible by integration"}`))
+		case "/repos/org/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"id": "not a number"}`))
+		}
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{
+		BaseURL: mockServer.URL,
+		Retry:   api.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+	}, logger)
+
+	_, err := githubApi.FetchRepository("org/private")
+	assert.ErrorIs(t, err, api.ErrForbidden)
+	assert.NotErrorIs(t, err, api.ErrRateLimited)
+
+	_, err = githubApi.FetchRepository("org/down")
+	assert.ErrorIs(t, err, api.ErrUpstream)
+	var statusErr *api.StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+
+	_, err = githubApi.FetchRepository("org/garbled")
+	assert.ErrorIs(t, err, api.ErrDecode)
+}
+
+func TestGraphQLFetchCommits(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graphql", r.URL.Path)
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "HEAD", body.Variables["expression"])
+		assert.Equal(t, "2023-01-01T00:00:00Z", body.Variables["since"])
+		w.Header().Set("X-RateLimit-Resource", "graphql")
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		if body.Variables["after"] == nil {
+			w.Write([]byte(`{"data": {"rateLimit": {"cost": 1}, "repository": {"object": {"history": {
+				"pageInfo": {"hasNextPage": true, "endCursor": "c1"},
+				"nodes": [{"oid": "abc123", "message": "init", "additions": 3, "deletions": 1,
+					"author": {"name": "tobi", "email": "tobi@example.com", "date": "2023-02-01T10:00:00Z", "user": {"login": "tobi", "databaseId": 7}},
+					"committer": {"name": "GitHub", "email": "noreply@github.com", "date": "2023-02-01T10:00:00Z"},
+					"parents": {"nodes": [{"oid": "def456"}]}, "signature": {"isValid": true, "state": "VALID"}}]}}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"rateLimit": {"cost": 1}, "repository": {"object": {"history": {
+			"pageInfo": {"hasNextPage": false},
+			"nodes": [{"oid": "def456", "message": "root", "author": {"name": "ada"}, "committer": {"name": "ada"}, "parents": {"nodes": []}}]}}}}}`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGraphQLAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	commits, cursors, err := collectCommits(githubApi, "org/repo", models.CommitConfig{StartDate: "2023-01-01"})
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, []string{"abc123", "def456"}, cursors)
+	assert.Equal(t, "abc123", commits[0].Hash)
+	assert.Equal(t, "tobi", commits[0].Author)
+	assert.Equal(t, "tobi@example.com", commits[0].AuthorEmail)
+	assert.Equal(t, mockServer.URL+"/repos/org/repo/git/commits/abc123", commits[0].URL)
+	assert.Equal(t, "tobi", commits[0].AuthorLogin)
+	assert.Equal(t, "noreply@github.com", commits[0].CommitterEmail)
+	assert.True(t, commits[0].Verified)
+	assert.Equal(t, "valid", commits[0].VerificationReason)
+	assert.False(t, commits[1].Verified)
+	assert.Equal(t, "unsigned", commits[1].VerificationReason)
+
+	status := githubApi.RateLimitStatus()
+	assert.Equal(t, "graphql", status[0].Resource)
+}
+
+func TestGraphQLFetchCommitsOfMissingBranch(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// no rate limit headers: the points left in the body are all there is
+		w.Write([]byte(`{"data": {"rateLimit": {"cost": 1, "limit": 5000, "remaining": 42, "used": 4958, "resetAt": "2030-01-01T00:00:00Z"},
+			"repository": {"object": null}}}`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGraphQLAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	_, _, err := collectCommits(githubApi, "org/repo", models.CommitConfig{Branch: "gone"})
+	assert.ErrorIs(t, err, api.ErrNotFound)
+
+	status := githubApi.RateLimitStatus()
+	if assert.Len(t, status, 1) {
+		assert.Equal(t, "graphql", status[0].Resource)
+		assert.Equal(t, 42, status[0].Remaining)
+		assert.Equal(t, 5000, status[0].Limit)
+	}
+}
+
+func TestFetchCommitsStreamsPages(t *testing.T) {
+	var mockServer *httptest.Server
+	mockServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/org/repo/commits?page=2>; rel="next"`, mockServer.URL))
+			w.Write([]byte(`[{"sha": "c3"}, {"sha": "c2"}]`))
+			return
+		}
+		w.Write([]byte(`[{"sha": "c1"}]`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	var pages [][]models.Commit
+	var cursors []string
+	err := githubApi.FetchCommits("org/repo", 1, models.CommitConfig{}, func(page []models.Commit, cursor string) error {
+		pages = append(pages, page)
+		cursors = append(cursors, cursor)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, pages, 2)
+	assert.Equal(t, []string{"c2", "c1"}, cursors)
+
+	stop := fmt.Errorf("storage failed")
+	calls := 0
+	err = githubApi.FetchCommits("org/repo", 1, models.CommitConfig{}, func(page []models.Commit, cursor string) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func TestFetchCommitDetailFollowsFilePages(t *testing.T) {
+	var mockServer *httptest.Server
+	mockServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/org/repo/commits/abc123", r.URL.Path)
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/org/repo/commits/abc123?page=2>; rel="next"`, mockServer.URL))
+			w.Write([]byte(`{"sha": "abc123", "stats": {"additions": 12, "deletions": 3, "total": 15},
+				"files": [{"filename": "a.go", "status": "modified", "additions": 10, "deletions": 3, "changes": 13}]}`))
+			return
+		}
+		w.Write([]byte(`{"sha": "abc123", "files": [{"filename": "b.go", "status": "renamed", "additions": 2, "changes": 2, "previous_filename": "old.go"}]}`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	detail, err := githubApi.FetchCommitDetail("org/repo", "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, 15, detail.Stats.Total)
+	assert.Len(t, detail.Files, 2)
+	assert.Equal(t, "old.go", detail.Files[1].PreviousFilename)
+}
+
+func TestFetchFile(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/org/repo/contents/.mailmap", r.URL.Path)
+		// "Tobi <tobi@example.com>\n" wrapped the way GitHub wraps base64 content
+		w.Write([]byte(`{"encoding": "base64", "content": "VG9iaSA8dG9iaUBleGFt\ncGxlLmNvbT4K\n"}`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	content, err := githubApi.FetchFile("org/repo", ".mailmap")
+	assert.NoError(t, err)
+	assert.Equal(t, "Tobi <tobi@example.com>\n", string(content))
+}
+
+func TestFetchCommitsOfBranch(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "release/1.0", r.URL.Query().Get("sha"))
+		w.Write([]byte(`[{"sha": "r1", "commit": {"message": "fix", "author": {"name": "tobi", "date": "2024-01-01T00:00:00Z"}}}]`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	var fetched []models.Commit
+	err := githubApi.FetchCommits("org/repo", 1, models.CommitConfig{Branch: "release/1.0"}, func(commits []models.Commit, _ string) error {
+		fetched = append(fetched, commits...)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, fetched, 1)
+}
+
+func TestListBranches(t *testing.T) {
+	var mockServer *httptest.Server
+	mockServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/org/repo/branches", r.URL.Path)
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/org/repo/branches?per_page=100&page=2>; rel="next"`, mockServer.URL))
+			w.Write([]byte(`[{"name": "main"}, {"name": "release/1.0"}]`))
+			return
+		}
+		w.Write([]byte(`[{"name": "release/2.0"}]`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	branches, err := githubApi.ListBranches("org/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"main", "release/1.0", "release/2.0"}, branches)
+}
+
+func TestCompareCommits(t *testing.T) {
+	var mockServer *httptest.Server
+	mockServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/org/repo/compare/old...main", r.URL.Path)
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/org/repo/compare/old...main?per_page=100&page=2>; rel="next"`, mockServer.URL))
+			w.Write([]byte(`{"status": "diverged", "ahead_by": 2, "behind_by": 1, "merge_base_commit": {"sha": "base"}, "commits": [{"sha": "n1"}]}`))
+			return
+		}
+		w.Write([]byte(`{"status": "diverged", "commits": [{"sha": "n2"}]}`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	comparison, err := githubApi.CompareCommits("org/repo", "old", "main")
+	assert.NoError(t, err)
+	assert.Equal(t, models.RewriteDiverged, comparison.Status)
+	assert.Equal(t, "base", comparison.MergeBaseCommit.SHA)
+	assert.Len(t, comparison.Commits, 2)
+	assert.Equal(t, "n2", comparison.Commits[1].SHA)
+
+	requests := 0
+	statusServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "1", r.URL.Query().Get("per_page"))
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/org/repo/compare/old...main?per_page=1&page=2>; rel="next"`, mockServer.URL))
+		w.Write([]byte(`{"status": "behind", "behind_by": 40, "merge_base_commit": {"sha": "base"}, "commits": []}`))
+	}))
+	defer statusServer.Close()
+	githubApi = api.NewGitHubAPI(api.Options{BaseURL: statusServer.URL}, logger)
+	comparison, err = githubApi.CompareStatus("org/repo", "old", "main")
+	assert.NoError(t, err)
+	assert.Equal(t, models.RewriteBehind, comparison.Status)
+	assert.Equal(t, 1, requests)
+}
+
+func TestFetchPullRequestsStopsAtSince(t *testing.T) {
+	var mockServer *httptest.Server
+	pages := 0
+	mockServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "all", r.URL.Query().Get("state"))
+		assert.Equal(t, "updated", r.URL.Query().Get("sort"))
+		pages++
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/org/repo/pulls?state=all&sort=updated&page=2>; rel="next"`, mockServer.URL))
+		w.Write([]byte(`[
+			{"number": 7, "state": "closed", "user": {"login": "tobi"}, "updated_at": "2024-01-03T00:00:00Z",
+			 "merged_at": "2024-01-03T00:00:00Z", "merge_commit_sha": "m7", "base": {"ref": "main"}, "head": {"ref": "fix"}, "labels": [{"name": "bug"}]},
+			{"number": 5, "state": "open", "user": {"login": "ada"}, "updated_at": "2024-01-01T00:00:00Z", "merge_commit_sha": "test-merge"}
+		]`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	since := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var fetched []models.PullRequest
+	err := githubApi.FetchPullRequests("org/repo", 1, &since, func(prs []models.PullRequest) error {
+		fetched = append(fetched, prs...)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pages)
+	assert.Len(t, fetched, 1)
+	assert.Equal(t, "m7", fetched[0].MergeCommitSHA)
+	assert.Equal(t, "main", fetched[0].BaseBranch)
+	assert.Equal(t, []string{"bug"}, fetched[0].Labels)
+}
+
+func TestFetchIssuesSkipsPullRequests(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/org/repo/issues", r.URL.Path)
+		assert.Equal(t, "all", r.URL.Query().Get("state"))
+		assert.Equal(t, "2024-01-02T00:00:00Z", r.URL.Query().Get("since"))
+		w.Write([]byte(`[
+			{"number": 4, "state": "closed", "state_reason": "completed", "user": {"login": "tobi"}, "assignees": [{"login": "ada"}],
+			 "labels": [{"name": "bug"}], "milestone": {"title": "v1"}, "updated_at": "2024-01-03T00:00:00Z"},
+			{"number": 5, "state": "open", "user": {"login": "ada"}, "pull_request": {"url": "pulls/5"}}
+		]`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	since := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var fetched []models.Issue
+	err := githubApi.FetchIssues("org/repo", 1, &since, func(issues []models.Issue) error {
+		fetched = append(fetched, issues...)
+		return nil
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, fetched, 1) {
+		assert.Equal(t, 4, fetched[0].Number)
+		assert.Equal(t, []string{"ada"}, fetched[0].Assignees)
+		assert.Equal(t, []string{"bug"}, fetched[0].Labels)
+		assert.Equal(t, "v1", fetched[0].Milestone)
+	}
+}
+
+func TestFetchReleases(t *testing.T) {
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		switch r.URL.Path {
+		case "/repos/org/repo/tags":
+			w.Write([]byte(`[{"name": "v1.0", "commit": {"sha": "abc"}}]`))
+		case "/repos/org/repo/releases":
+			w.Write([]byte(`[{"id": 9, "tag_name": "v1.0", "name": "First", "target_commitish": "main", "prerelease": true,
+				"body": "notes", "author": {"login": "tobi"}, "published_at": "2024-01-01T00:00:00Z"}]`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	cache := &memoryCache{entries: map[string]*models.HTTPCacheEntry{}}
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL, Cache: cache}, logger)
+
+	var tags []models.Tag
+	err := githubApi.FetchTags("org/repo", 1, func(fetched []models.Tag) error {
+		tags = fetched
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Tag{{RepoID: 1, Name: "v1.0", SHA: "abc"}}, tags)
+
+	// releases that failed to be stored are fetched again in full
+	err = githubApi.FetchReleases("org/repo", 1, func([]models.Release) error { return fmt.Errorf("disk full") })
+	assert.Error(t, err)
+	var releases []models.Release
+	err = githubApi.FetchReleases("org/repo", 1, func(fetched []models.Release) error {
+		releases = fetched
+		return nil
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, releases, 1) {
+		assert.Equal(t, int64(9), releases[0].GitHubID)
+		assert.True(t, releases[0].Prerelease)
+		assert.Equal(t, "tobi", releases[0].AuthorLogin)
+		assert.NotNil(t, releases[0].PublishedAt)
+	}
+
+	// unchanged since they were stored: nothing is handed over
+	err = githubApi.FetchTags("org/repo", 1, func([]models.Tag) error {
+		t.Error("unchanged tags handled again")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, requests)
+}
+
+func TestFetchTagsChangedOnLaterPage(t *testing.T) {
+	lastTag := "v0.1-backport"
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var names []string
+		switch r.URL.Query().Get("page") {
+		case "1":
+			for i := 0; i < 100; i++ {
+				names = append(names, fmt.Sprintf("v1.%d", i))
+			}
+		case "2":
+			names = []string{lastTag}
+		default:
+			t.Errorf("unexpected page %s", r.URL.Query().Get("page"))
+		}
+		etag := fmt.Sprintf(`"%s"`, strings.Join(names, ","))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		var body []string
+		for _, name := range names {
+			body = append(body, fmt.Sprintf(`{"name": %q, "commit": {"sha": "abc"}}`, name))
+		}
+		w.Write([]byte("[" + strings.Join(body, ",") + "]"))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	cache := &memoryCache{entries: map[string]*models.HTTPCacheEntry{}}
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL, Cache: cache}, logger)
+
+	fetch := func() []models.Tag {
+		var tags []models.Tag
+		handled := false
+		assert.NoError(t, githubApi.FetchTags("org/repo", 1, func(fetched []models.Tag) error {
+			tags, handled = fetched, true
+			return nil
+		}))
+		if !handled {
+			return nil
+		}
+		return tags
+	}
+	assert.Len(t, fetch(), 101)
+	assert.Nil(t, fetch(), "unchanged tags handled again")
+
+	// a tag added past the first page, with the first page answered from the cache
+	lastTag = "v0.2-backport"
+	tags := fetch()
+	if assert.Len(t, tags, 101) {
+		assert.Equal(t, "v1.0", tags[0].Name)
+		assert.Equal(t, "v0.2-backport", tags[100].Name)
+	}
+}
+
+func TestFetchStatsWhileComputing(t *testing.T) {
+	calls := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/repo/stats/contributors":
+			calls++
+			if calls == 1 {
+				w.WriteHeader(http.StatusAccepted)
+				w.Write([]byte(`{}`))
+				return
+			}
+			w.Write([]byte(`[{"author": {"login": "tobi", "id": 1}, "total": 3, "weeks": [
+				{"w": 1704067200, "a": 10, "d": 2, "c": 3}, {"w": 1704672000, "a": 0, "d": 0, "c": 0}]}]`))
+		case "/repos/org/repo/stats/commit_activity":
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{
+		BaseURL: mockServer.URL,
+		Retry:   api.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	}, logger)
+
+	weeks, err := githubApi.FetchContributorStats("org/repo", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	// weeks without activity are left out
+	if assert.Len(t, weeks, 1) {
+		assert.Equal(t, "tobi", weeks[0].Login)
+		assert.Equal(t, 3, weeks[0].Commits)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), weeks[0].Week)
+	}
+
+	_, err = githubApi.FetchCommitActivity("org/repo", 1)
+	assert.ErrorIs(t, err, api.ErrComputing)
+}
+
+func TestFetchWorkflowRuns(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/repo/actions/runs":
+			assert.Equal(t, ">=2024-01-02T00:00:00Z", r.URL.Query().Get("created"))
+			w.Write([]byte(`{"total_count": 1, "workflow_runs": [{"id": 5, "name": "ci", "run_attempt": 2, "head_sha": "abc",
+				"event": "push", "status": "completed", "conclusion": "success", "created_at": "2024-01-03T00:00:00Z",
+				"run_started_at": "2024-01-03T00:01:00Z", "updated_at": "2024-01-03T00:11:00Z"}]}`))
+		case "/repos/org/repo/actions/runs/5/jobs":
+			assert.Equal(t, "all", r.URL.Query().Get("filter"))
+			w.Write([]byte(`{"total_count": 1, "jobs": [{"id": 7, "run_id": 5, "name": "test", "run_attempt": 1, "status": "completed", "conclusion": null}]}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	since := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var fetched []models.WorkflowRun
+	err := githubApi.FetchWorkflowRuns("org/repo", 1, &since, func(runs []models.WorkflowRun) error {
+		fetched = append(fetched, runs...)
+		return nil
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, fetched, 1) {
+		assert.Equal(t, "ci", fetched[0].WorkflowName)
+		assert.Equal(t, models.WorkflowSuccess, fetched[0].Conclusion)
+		assert.Equal(t, 10*time.Minute, fetched[0].CompletedAt().Sub(*fetched[0].StartedAt))
+	}
+
+	jobs, err := githubApi.FetchWorkflowJobs("org/repo", 1, 5)
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 1) {
+		assert.Equal(t, int64(5), jobs[0].RunID)
+		assert.Empty(t, jobs[0].Conclusion)
+	}
+}
+
+func TestListOwnerRepositories(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/org":
+			w.Write([]byte(`{"login": "org", "type": "Organization"}`))
+		case "/orgs/org/repos":
+			assert.Equal(t, "all", r.URL.Query().Get("type"))
+			w.Write([]byte(`[{"full_name": "org/a", "name": "a", "fork": true, "visibility": "private", "archived": true, "topics": ["cli"]}]`))
+		case "/users/someone":
+			w.Write([]byte(`{"login": "someone", "type": "User"}`))
+		case "/users/someone/repos":
+			assert.Equal(t, "owner", r.URL.Query().Get("type"))
+			w.Write([]byte(`[]`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	repos, isOrg, err := githubApi.ListOwnerRepositories("org")
+	assert.NoError(t, err)
+	assert.True(t, isOrg)
+	if assert.Len(t, repos, 1) {
+		assert.Equal(t, "org/a", repos[0].FullName)
+		assert.True(t, repos[0].Fork)
+		assert.True(t, repos[0].Archived)
+		assert.Equal(t, "private", repos[0].Visibility)
+		assert.Equal(t, []string{"cli"}, repos[0].Topics)
+	}
+
+	repos, isOrg, err = githubApi.ListOwnerRepositories("someone")
+	assert.NoError(t, err)
+	assert.False(t, isOrg)
+	assert.Empty(t, repos)
+}
+
+func TestListOwnerRepositoriesAsApp(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/users/org/installation":
+			assert.Len(t, strings.Split(strings.TrimPrefix(auth, "Bearer "), "."), 3)
+			w.Write([]byte(`{"id": 7}`))
+		case "/app/installations/7/access_tokens":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "ghs_org", "expires_at": "%s"}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+		case "/users/org":
+			assert.Equal(t, "Bearer ghs_org", auth)
+			w.Write([]byte(`{"login": "org", "type": "Organization"}`))
+		case "/orgs/org/repos":
+			assert.Equal(t, "Bearer ghs_org", auth)
+			w.Write([]byte(`[{"full_name": "org/secret", "name": "secret", "visibility": "private"}]`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer mockServer.Close()
+
+	// no static token: the listing must not fall back to anonymous requests
+	app, err := api.NewAppAuth("1234", privateKey, nil)
+	assert.NoError(t, err)
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL, App: app}, logger)
+
+	repos, isOrg, err := githubApi.ListOwnerRepositories("org")
+	assert.NoError(t, err)
+	assert.True(t, isOrg)
+	if assert.Len(t, repos, 1) {
+		assert.Equal(t, "org/secret", repos[0].FullName)
+		assert.Equal(t, int64(7), repos[0].InstallationID)
+	}
+}
+
+func TestFetchPullRequestReviews(t *testing.T) {
+	var mockServer *httptest.Server
+	mockServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/org/repo/pulls/7/reviews", r.URL.Path)
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/org/repo/pulls/7/reviews?page=2>; rel="next"`, mockServer.URL))
+			w.Write([]byte(`[{"id": 1, "user": {"login": "ada", "id": 2}, "state": "APPROVED", "submitted_at": "2024-01-01T00:00:00Z"}]`))
+			return
+		}
+		w.Write([]byte(`[{"id": 2, "user": {"login": "grace"}, "state": "COMMENTED", "submitted_at": "2024-01-02T00:00:00Z"}]`))
+	}))
+	defer mockServer.Close()
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: mockServer.URL}, logger)
+
+	reviews, err := githubApi.FetchPullRequestReviews("org/repo", 1, 7)
 	assert.NoError(t, err)
-	assert.NotNil(t, commits)
-	assert.Equal(t, 0, rl)
+	assert.Len(t, reviews, 2)
+	assert.Equal(t, models.ReviewApproved, reviews[0].State)
+	assert.Equal(t, 7, reviews[1].Number)
 }
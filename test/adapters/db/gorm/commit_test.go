@@ -1,12 +1,15 @@
 package gorm_test
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	gm "gorm.io/gorm"
@@ -17,7 +20,7 @@ var dbFilePath = "test.db"
 
 func setupTestDB() *gm.DB {
 	db, _ = gm.Open(sqlite.Open(dbFilePath), &gm.Config{})
-	db.AutoMigrate(&models.Commit{})
+	gorm.Migrate(db)
 	return db
 }
 func teardownTestDB() {
@@ -67,3 +70,286 @@ func TestFindAll(t *testing.T) {
 	assert.Equal(t, 1, len(found))
 	teardownTestDB()
 }
+
+func TestUpsertPage(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&models.Repository{ID: 1, FullName: "org/repo"})
+	repo := gorm.NewCommitRepo(db)
+
+	err := repo.UpsertPage(1, "", []models.Commit{{Hash: "c2", RepoID: 1, Message: "old"}, {Hash: "c1", RepoID: 1}}, "c1")
+	assert.NoError(t, err)
+	err = repo.UpsertPage(1, "", []models.Commit{{Hash: "c2", RepoID: 1, Message: "new"}}, "c2")
+	assert.NoError(t, err)
+
+	count, _ := repo.Count()
+	assert.Equal(t, int64(2), count)
+	found, _ := repo.FindByHash("c2")
+	assert.Equal(t, "new", found.Message)
+	var stored models.Repository
+	db.First(&stored, 1)
+	assert.Equal(t, "c2", stored.LastCommitSHA)
+	teardownTestDB()
+}
+
+func TestUpsertPageKeepsFirstRepo(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&models.Repository{ID: 1, FullName: "org/repo"})
+	db.Create(&models.Repository{ID: 2, FullName: "fork/repo", Fork: true})
+	repo := gorm.NewCommitRepo(db)
+
+	assert.NoError(t, repo.UpsertPage(1, "", []models.Commit{{Hash: "shared", RepoID: 1}}, "shared"))
+	assert.NoError(t, repo.UpsertPage(2, "", []models.Commit{{Hash: "own", RepoID: 2}, {Hash: "shared", RepoID: 2}}, "shared"))
+
+	found, _ := repo.FindByHash("shared")
+	assert.Equal(t, uint(1), found.RepoID)
+	found, _ = repo.FindByHash("own")
+	assert.Equal(t, uint(2), found.RepoID)
+	teardownTestDB()
+}
+
+func TestUpsertPageOnForkBranch(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&models.Repository{ID: 1, FullName: "org/repo"})
+	db.Create(&models.Repository{ID: 2, FullName: "fork/repo", Fork: true})
+	commits := gorm.NewCommitRepo(db)
+
+	assert.NoError(t, commits.UpsertPage(1, "main", []models.Commit{{Hash: "shared", RepoID: 1}}, "shared"))
+	assert.NoError(t, commits.UpsertPage(2, "main", []models.Commit{{Hash: "own", RepoID: 2}, {Hash: "shared", RepoID: 2}}, "shared"))
+
+	hashes := func(repoID uint) []string {
+		found, err := commits.FindByRepoId(repoID, types.CommitFilter{Branch: "main"}, 1, 10)
+		assert.NoError(t, err)
+		var out []string
+		for _, cmt := range found {
+			out = append(out, cmt.Hash)
+		}
+		return out
+	}
+	// the shared commit belongs to the parent and is not linked to the fork's branch
+	assert.Equal(t, []string{"shared"}, hashes(1))
+	assert.Equal(t, []string{"own"}, hashes(2))
+	var links int64
+	db.Model(&models.CommitBranch{}).Where("repo_id = ?", 2).Count(&links)
+	assert.Equal(t, int64(1), links)
+	teardownTestDB()
+}
+
+func TestUpsertPageOnBranch(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&models.Repository{ID: 1, FullName: "org/repo", DefaultBranch: "main", LastCommitSHA: "m1"})
+	commits := gorm.NewCommitRepo(db)
+	repos := gorm.NewRepository(db)
+
+	assert.NoError(t, commits.UpsertPage(1, "", []models.Commit{{Hash: "m2", RepoID: 1}, {Hash: "shared", RepoID: 1}}, ""))
+	assert.NoError(t, commits.UpsertPage(1, "release/1.0", []models.Commit{{Hash: "r1", RepoID: 1}, {Hash: "shared", RepoID: 1}}, "shared"))
+
+	var stored models.Repository
+	db.First(&stored, 1)
+	assert.Equal(t, "m1", stored.LastCommitSHA, "an empty cursor must not move the default branch cursor")
+	branches, err := repos.FindBranches(1)
+	assert.NoError(t, err)
+	assert.Len(t, branches, 1)
+	assert.Equal(t, "release/1.0", branches[0].Name)
+	assert.Equal(t, "shared", branches[0].LastCommitSHA)
+
+	hashes := func(branch string) []string {
+		found, err := commits.FindByRepoId(1, types.CommitFilter{Branch: branch}, 1, 10)
+		assert.NoError(t, err)
+		var out []string
+		for _, cmt := range found {
+			out = append(out, cmt.Hash)
+		}
+		return out
+	}
+	assert.ElementsMatch(t, []string{"m2", "shared"}, hashes("main"))
+	assert.ElementsMatch(t, []string{"r1", "shared"}, hashes("release/1.0"))
+	assert.Len(t, hashes(""), 3)
+
+	assert.NoError(t, repos.UpdateBranchPatterns(1, []string{"release/*"}))
+	found, err := repos.FindByName("org/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"release/*"}, found.BranchPatterns)
+	teardownTestDB()
+}
+
+func TestWatermarks(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&models.Repository{ID: 1, FullName: "org/repo"})
+	commits := gorm.NewCommitRepo(db)
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	page := []models.Commit{{Hash: "c3", RepoID: 1, CommitterDate: day(3)}, {Hash: "c2", RepoID: 1, CommitterDate: day(2)}}
+	assert.NoError(t, commits.UpsertPage(1, "", page, "c2"))
+	assert.NoError(t, commits.AdvanceHead(1, "", page[0]))
+	// an older head never moves the watermark back
+	assert.NoError(t, commits.AdvanceHead(1, "", models.Commit{Hash: "c1", CommitterDate: day(1)}))
+
+	var stored models.Repository
+	db.First(&stored, 1)
+	assert.Equal(t, "c3", stored.HeadSHA)
+	assert.True(t, day(3).Equal(*stored.HeadDate))
+	assert.Equal(t, "c2", stored.LastCommitSHA)
+	assert.True(t, day(2).Equal(*stored.BackfillDate))
+
+	assert.NoError(t, commits.AdvanceHead(1, "release/1.0", models.Commit{Hash: "r1", CommitterDate: day(4)}))
+	branches, _ := gorm.NewRepository(db).FindBranches(1)
+	assert.Len(t, branches, 1)
+	assert.Equal(t, "r1", branches[0].HeadSHA)
+
+	newest, err := commits.FindNewest(1, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "c3", newest.Hash)
+	teardownTestDB()
+}
+
+func TestRecordRewrite(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&models.Repository{ID: 1, FullName: "org/repo", DefaultBranch: "main"})
+	commits := gorm.NewCommitRepo(db)
+	assert.NoError(t, commits.UpsertPage(1, "", []models.Commit{{Hash: "a", RepoID: 1}, {Hash: "b", RepoID: 1}, {Hash: "base", RepoID: 1}}, "b"))
+	assert.NoError(t, commits.UpsertPage(1, "release/1.0", []models.Commit{{Hash: "a", RepoID: 1}}, "a"))
+
+	rewrite := &models.HistoryRewrite{RepoID: 1, Branch: "main", Protected: true, Status: models.RewriteDiverged, OldSHA: "b", MergeBaseSHA: "base"}
+	assert.NoError(t, commits.RecordRewrite(rewrite, []string{"b", "a"}))
+	assert.Equal(t, 1, rewrite.OrphanedCount, "a is still on release/1.0")
+
+	orphaned := true
+	found, err := commits.FindByRepoId(1, types.CommitFilter{Orphaned: &orphaned}, 1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "b", found[0].Hash)
+	assert.NotNil(t, found[0].OrphanedAt)
+	var stored models.Repository
+	db.First(&stored, 1)
+	assert.Empty(t, stored.LastCommitSHA)
+
+	protected := true
+	rewrites, err := commits.FindRewrites(1, &protected, 1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, rewrites, 1)
+	assert.Equal(t, "b", rewrites[0].OldSHA)
+
+	// fetched again from the new head, the commit is reachable again
+	assert.NoError(t, commits.UpsertPage(1, "", []models.Commit{{Hash: "b", RepoID: 1}}, "b"))
+	found, _ = commits.FindByRepoId(1, types.CommitFilter{Orphaned: &orphaned}, 1, 10)
+	assert.Empty(t, found)
+	teardownTestDB()
+}
+
+func TestFindRewritesPages(t *testing.T) {
+	db := setupTestDB()
+	commits := gorm.NewCommitRepo(db)
+	for i := 1; i <= 3; i++ {
+		db.Create(&models.HistoryRewrite{RepoID: 1, Branch: "main", Status: models.RewriteDiverged, OldSHA: fmt.Sprint("c", i),
+			DetectedAt: time.Date(2024, 1, i, 0, 0, 0, 0, time.UTC)})
+	}
+
+	rewrites, err := commits.FindRewrites(1, nil, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c3", "c2", "c1"}, rewriteSHAs(rewrites))
+	rewrites, err = commits.FindRewrites(1, nil, 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c1"}, rewriteSHAs(rewrites))
+	teardownTestDB()
+}
+
+func rewriteSHAs(rewrites []models.HistoryRewrite) []string {
+	shas := make([]string, 0, len(rewrites))
+	for _, rewrite := range rewrites {
+		shas = append(shas, rewrite.OldSHA)
+	}
+	return shas
+}
+
+func TestMigrateKeepsLegacyCommits(t *testing.T) {
+	db, _ = gm.Open(sqlite.Open(dbFilePath), &gm.Config{})
+	// the commits table as created before committer, parents and verification were stored
+	db.Exec(`CREATE TABLE commits (id integer PRIMARY KEY AUTOINCREMENT, repo_id integer NOT NULL, hash text NOT NULL UNIQUE,
+		message text, author text, author_email text, date datetime, url text, created_at datetime, updated_at datetime)`)
+	db.Exec(`INSERT INTO commits (repo_id, hash, message, author) VALUES (1, 'legacy', 'old', 'tobi')`)
+
+	assert.NoError(t, gorm.Migrate(db))
+	repo := gorm.NewCommitRepo(db)
+	found, err := repo.FindByHash("legacy")
+	assert.NoError(t, err)
+	assert.False(t, found.IsMerge)
+	assert.Empty(t, found.Parents)
+
+	err = repo.UpsertCommits([]models.Commit{{Hash: "legacy", RepoID: 1, Parents: []string{"p1", "p2"}, IsMerge: true, Verified: true}})
+	assert.NoError(t, err)
+	merge := true
+	merges, err := repo.FindByRepoId(1, types.CommitFilter{Merge: &merge}, 1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, merges, 1)
+	assert.Equal(t, []string{"p1", "p2"}, merges[0].Parents)
+	teardownTestDB()
+}
+
+func TestSaveEnrichment(t *testing.T) {
+	db := setupTestDB()
+	repo := gorm.NewCommitRepo(db)
+	repo.UpsertCommits([]models.Commit{{Hash: "c1", RepoID: 1}, {Hash: "c2", RepoID: 1}})
+
+	pending, err := repo.FindUnenriched(1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+
+	cmt := pending[0]
+	cmt.Additions, cmt.Deletions, cmt.TotalChanges = 5, 1, 6
+	files := []models.CommitFile{{CommitID: cmt.ID, RepoID: 1, Filename: "main.go", Status: "modified", Additions: 5, Deletions: 1, Changes: 6}}
+	assert.NoError(t, repo.SaveEnrichment(&cmt, files))
+	// saving again, as a resumed run would, replaces the files
+	assert.NoError(t, repo.SaveEnrichment(&cmt, files))
+
+	pending, _ = repo.FindUnenriched(1, 10)
+	assert.Len(t, pending, 1)
+	found, _ := repo.FindByHash(cmt.Hash)
+	assert.Equal(t, 6, found.TotalChanges)
+	assert.NotNil(t, found.EnrichedAt)
+	var stored []models.CommitFile
+	db.Where("commit_id = ?", cmt.ID).Find(&stored)
+	assert.Len(t, stored, 1)
+
+	// a later list fetch of the same commit keeps its stats
+	repo.UpsertCommits([]models.Commit{{Hash: cmt.Hash, RepoID: 1, Message: "again"}})
+	found, _ = repo.FindByHash(cmt.Hash)
+	assert.Equal(t, 6, found.TotalChanges)
+	teardownTestDB()
+}
+
+func TestTopCommitAuthorsWithCoAuthors(t *testing.T) {
+	db := setupTestDB()
+	repo := gorm.NewCommitRepo(db)
+	err := repo.UpsertCommits([]models.Commit{
+		{Hash: "c1", RepoID: 1, Author: "tobi", AuthorEmail: "tobi@example.com", Message: "pair\n\nCo-authored-by: ada <ada@example.com>"},
+		{Hash: "c2", RepoID: 1, Author: "tobi", AuthorEmail: "tobi@example.com", Message: "solo\n\nCo-authored-by: tobi <tobi@example.com>"},
+		{Hash: "c3", RepoID: 1, Author: "ada", AuthorEmail: "ada@example.com", Message: "fix\n\nSigned-off-by: grace <grace@example.com>"},
+	})
+	assert.NoError(t, err)
+
+	authors, err := repo.GetTopCommitAuthors(1, 10, false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"tobi": 2, "ada": 1}, commitCounts(authors))
+
+	authors, err = repo.GetTopCommitAuthors(1, 10, true)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"tobi": 2, "ada": 2}, commitCounts(authors))
+
+	// rows from before participants were recorded are picked up by reprocessing
+	db.Where("1 = 1").Delete(&models.CommitParticipant{})
+	processed, err := repo.Reprocess(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, processed)
+	var count int64
+	db.Model(&models.CommitParticipant{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+	teardownTestDB()
+}
+
+func commitCounts(authors []types.AuthorCommitsCount) map[string]int {
+	counts := make(map[string]int)
+	for _, author := range authors {
+		counts[author.Author] = author.CommitCount
+	}
+	return counts
+}
@@ -0,0 +1,52 @@
+package gorm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrollments(t *testing.T) {
+	db := setupTestDB()
+	enrollments := gorm.NewEnrollmentRepo(db)
+	repos := gorm.NewRepository(db)
+
+	first := &models.Enrollment{Owner: "org", Visibility: models.VisibilityAll}
+	assert.NoError(t, enrollments.Save(first))
+	assert.NotZero(t, first.ID)
+	// enrolling the owner again replaces its filters and keeps its ID
+	again := &models.Enrollment{Owner: "org", Visibility: models.VisibilityPublic, Language: "Go"}
+	assert.NoError(t, enrollments.Save(again))
+	assert.Equal(t, first.ID, again.ID)
+	assert.NoError(t, enrollments.MarkSynced(again.ID, true, time.Now()))
+	stored, err := enrollments.FindByOwner("org")
+	assert.NoError(t, err)
+	assert.Equal(t, "Go", stored.Language)
+	assert.True(t, stored.IsOrg)
+	assert.NotNil(t, stored.SyncedAt)
+
+	assert.NoError(t, repos.Create(&models.Repository{FullName: "org/a", EnrollmentID: first.ID}))
+	assert.NoError(t, repos.Create(&models.Repository{FullName: "org/b"}))
+	b, _ := repos.FindByName("org/b")
+	assert.Equal(t, models.RepositoryActive, b.Status)
+	assert.NoError(t, repos.UpdateEnrollment(b.ID, first.ID))
+	assert.NoError(t, repos.UpdateStatus(b.ID, models.RepositoryInactive))
+	enrolled, err := repos.FindByEnrollment(first.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, enrolled, 2) {
+		assert.Equal(t, "org/b", enrolled[1].FullName)
+		assert.Equal(t, models.RepositoryInactive, enrolled[1].Status)
+	}
+
+	// deleting the enrollment keeps its repositories
+	assert.NoError(t, enrollments.Delete("org"))
+	assert.Error(t, enrollments.Delete("org"))
+	enrolled, _ = repos.FindByEnrollment(first.ID)
+	assert.Empty(t, enrolled)
+	all, _ := repos.FindAll()
+	assert.Len(t, all, 2)
+	teardownTestDB()
+}
@@ -0,0 +1,23 @@
+package gorm_test
+
+import (
+	"testing"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPCacheSaveReplacesValidators(t *testing.T) {
+	db := setupTestDB()
+	cache := gorm.NewHTTPCacheRepo(db)
+	url := "https://api.github.com/repos/org/repo"
+
+	assert.NoError(t, cache.Save(&models.HTTPCacheEntry{URL: url, ETag: `"v1"`}))
+	assert.NoError(t, cache.Save(&models.HTTPCacheEntry{URL: url, ETag: `"v2"`, Body: []byte("{}")}))
+	entry, err := cache.Get(url)
+	assert.NoError(t, err)
+	assert.Equal(t, `"v2"`, entry.ETag)
+	assert.Equal(t, "{}", string(entry.Body))
+	teardownTestDB()
+}
@@ -0,0 +1,62 @@
+package gorm_test
+
+import (
+	"testing"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityResolution(t *testing.T) {
+	db := setupTestDB()
+	commits := gorm.NewCommitRepo(db)
+	identities := gorm.NewIdentityRepo(db)
+
+	err := commits.UpsertCommits([]models.Commit{
+		{Hash: "c1", RepoID: 1, Author: "Tobi", AuthorEmail: "tobi@work.com"},
+		{Hash: "c2", RepoID: 1, Author: "tobi", AuthorEmail: "tobi@home.com"},
+		{Hash: "c3", RepoID: 1, Author: "Ada", AuthorEmail: "ada@example.com", AuthorID: 7, AuthorLogin: "ada"},
+		{Hash: "c4", RepoID: 1, Author: "Ada L", AuthorEmail: "ADA@example.com"},
+	})
+	assert.NoError(t, err)
+	// the GitHub user ID links a new email to ada
+	err = commits.UpsertCommits([]models.Commit{
+		{Hash: "c5", RepoID: 1, Author: "ada", AuthorEmail: "ada@personal.com", AuthorID: 7, AuthorLogin: "ada"},
+	})
+	assert.NoError(t, err)
+
+	authors, err := commits.GetTopCommitAuthors(1, 10, false)
+	assert.NoError(t, err)
+	assert.Len(t, authors, 3)
+	assert.Equal(t, "Ada", authors[0].Author)
+	assert.Equal(t, 3, authors[0].CommitCount)
+	assert.ElementsMatch(t, []string{"Ada <ada@example.com>", "Ada L <ada@example.com>", "ada <ada@personal.com>"}, authors[0].Aliases)
+
+	// a .mailmap entry merges tobi's two emails once identities are rebuilt
+	err = identities.ReplaceRules("mailmap", []models.AliasRule{
+		{CanonicalName: "Tobi", CanonicalEmail: "tobi@work.com", MatchEmail: "tobi@home.com", Source: "mailmap"},
+	})
+	assert.NoError(t, err)
+	processed, err := identities.Rebuild(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, processed)
+
+	authors, err = commits.GetTopCommitAuthors(1, 10, false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"Ada": 3, "Tobi": 2}, commitCounts(authors))
+
+	found, err := identities.FindAll(1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+	// a page of one is followed by the first identity of the next page
+	first, err := identities.FindAll(1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, first, 2)
+	second, err := identities.FindAll(2, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, second, 1) {
+		assert.Equal(t, first[1].ID, second[0].ID)
+	}
+	teardownTestDB()
+}
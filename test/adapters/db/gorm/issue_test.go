@@ -0,0 +1,70 @@
+package gorm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssues(t *testing.T) {
+	db := setupTestDB()
+	commits := gorm.NewCommitRepo(db)
+	issues := gorm.NewIssueRepo(db)
+	closed := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	err := issues.UpsertIssues([]models.Issue{
+		{RepoID: 1, Number: 1, State: "open", AuthorLogin: "tobi", Labels: []string{"bug"}, Assignees: []string{"ada"}},
+		{RepoID: 1, Number: 2, State: "open", AuthorLogin: "ada", Milestone: "v1"},
+	})
+	assert.NoError(t, err)
+	// a refreshed copy replaces the stored one
+	err = issues.UpsertIssues([]models.Issue{{RepoID: 1, Number: 1, Title: "crash", State: "closed", ClosedAt: &closed, Labels: []string{"bug"}}})
+	assert.NoError(t, err)
+	first, err := issues.FindByNumber(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "crash", first.Title)
+	assert.Equal(t, "closed", first.State)
+	assert.Empty(t, first.Assignees)
+
+	numbers := func(filter types.IssueFilter) []int {
+		found, err := issues.FindByRepoId(1, filter, 1, 10)
+		assert.NoError(t, err)
+		var out []int
+		for _, issue := range found {
+			out = append(out, issue.Number)
+		}
+		return out
+	}
+	assert.Equal(t, []int{2, 1}, numbers(types.IssueFilter{}))
+	assert.Equal(t, []int{1}, numbers(types.IssueFilter{State: "closed", Label: "bug"}))
+	assert.Equal(t, []int{2}, numbers(types.IssueFilter{Milestone: "v1"}))
+	page, err := issues.FindByRepoId(1, types.IssueFilter{}, 2, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, 1, page[0].Number)
+	}
+
+	assert.NoError(t, commits.UpsertCommits([]models.Commit{
+		{Hash: "a", RepoID: 1, Message: "Investigate #1", Date: closed.Add(-time.Hour)},
+		{Hash: "b", RepoID: 1, Message: "Fixes #1 and #2", Date: closed},
+	}))
+	refs, err := issues.FindReferencingCommits(1, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, refs, 2) {
+		assert.Equal(t, "a", refs[0].Hash)
+		assert.False(t, refs[0].Closes)
+		assert.Equal(t, "b", refs[1].Hash)
+		assert.True(t, refs[1].Closes)
+	}
+
+	// an amended message replaces the references of the commit
+	assert.NoError(t, commits.UpsertCommits([]models.Commit{{Hash: "b", RepoID: 1, Message: "Fixes #1", Date: closed}}))
+	refs, err = issues.FindReferencingCommits(1, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, refs)
+	teardownTestDB()
+}
@@ -0,0 +1,104 @@
+package gorm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullRequests(t *testing.T) {
+	db := setupTestDB()
+	commits := gorm.NewCommitRepo(db)
+	prs := gorm.NewPullRequestRepo(db)
+	merged := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, commits.UpsertCommits([]models.Commit{{Hash: "m1", RepoID: 1}}))
+	err := prs.UpsertPullRequests([]models.PullRequest{
+		{RepoID: 1, Number: 1, State: models.PullRequestClosed, AuthorLogin: "tobi", MergedAt: &merged, MergeCommitSHA: "m1", Labels: []string{"bug"}},
+		{RepoID: 1, Number: 2, State: models.PullRequestClosed, AuthorLogin: "ada", MergedAt: &merged, MergeCommitSHA: "m2"},
+		{RepoID: 1, Number: 3, State: models.PullRequestOpen, AuthorLogin: "tobi", Labels: []string{"bug fix"}},
+	})
+	assert.NoError(t, err)
+
+	first, err := prs.FindByNumber(1, 1)
+	assert.NoError(t, err)
+	assert.NotZero(t, first.MergeCommitID)
+	second, _ := prs.FindByNumber(1, 2)
+	assert.Zero(t, second.MergeCommitID)
+	// the merge commit of #2 arrives later
+	assert.NoError(t, commits.UpsertCommits([]models.Commit{{Hash: "m2", RepoID: 1}}))
+	second, _ = prs.FindByNumber(1, 2)
+	assert.NotZero(t, second.MergeCommitID)
+
+	// a refreshed copy keeps its link
+	assert.NoError(t, prs.UpsertPullRequests([]models.PullRequest{{RepoID: 1, Number: 1, Title: "renamed", State: models.PullRequestClosed, MergedAt: &merged, MergeCommitSHA: "m1"}}))
+	first, _ = prs.FindByNumber(1, 1)
+	assert.Equal(t, "renamed", first.Title)
+	assert.NotZero(t, first.MergeCommitID)
+
+	numbers := func(filter types.PullRequestFilter) []int {
+		found, err := prs.FindByRepoId(1, filter, 1, 10)
+		assert.NoError(t, err)
+		var out []int
+		for _, pr := range found {
+			out = append(out, pr.Number)
+		}
+		return out
+	}
+	yes := true
+	assert.Equal(t, []int{3, 2, 1}, numbers(types.PullRequestFilter{}))
+	assert.Equal(t, []int{2, 1}, numbers(types.PullRequestFilter{Merged: &yes}))
+	assert.Equal(t, []int{3}, numbers(types.PullRequestFilter{State: models.PullRequestOpen, Author: "tobi"}))
+	assert.Equal(t, []int{3}, numbers(types.PullRequestFilter{Label: "bug fix"}))
+	page, err := prs.FindByRepoId(1, types.PullRequestFilter{}, 2, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, 1, page[0].Number)
+	}
+	teardownTestDB()
+}
+
+func TestReviewMetrics(t *testing.T) {
+	db := setupTestDB()
+	prs := gorm.NewPullRequestRepo(db)
+	at := func(hour int) *time.Time {
+		ts := time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)
+		return &ts
+	}
+
+	err := prs.UpsertPullRequests([]models.PullRequest{
+		{RepoID: 1, Number: 1, State: models.PullRequestClosed, AuthorLogin: "tobi", CreatedAt: *at(0), MergedAt: at(10)},
+		{RepoID: 1, Number: 2, State: models.PullRequestOpen, AuthorLogin: "ada", CreatedAt: *at(1)},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, prs.SaveReviews(1, 1, []models.PullRequestReview{
+		{GitHubID: 1, RepoID: 1, Number: 1, ReviewerLogin: "tobi", State: models.ReviewCommented, SubmittedAt: at(1)},
+		{GitHubID: 2, RepoID: 1, Number: 1, ReviewerLogin: "ada", State: models.ReviewChangesRequested, SubmittedAt: at(2)},
+		{GitHubID: 3, RepoID: 1, Number: 1, ReviewerLogin: "ada", State: models.ReviewApproved, SubmittedAt: at(5)},
+	}, []models.ReviewComment{{GitHubID: 9, RepoID: 1, Number: 1, ReviewID: 2, AuthorLogin: "ada", CreatedAt: *at(2)}}))
+	assert.NoError(t, prs.SaveReviews(1, 2, []models.PullRequestReview{
+		{GitHubID: 4, RepoID: 1, Number: 2, ReviewerLogin: "grace", State: models.ReviewApproved, SubmittedAt: at(3)},
+		{GitHubID: 5, RepoID: 1, Number: 2, ReviewerLogin: "grace", State: models.ReviewPending},
+	}, nil))
+
+	window := types.DateWindow{Since: *at(0), Until: *at(24)}
+	timings, err := prs.FindReviewTimings(1, window)
+	assert.NoError(t, err)
+	assert.Len(t, timings, 2)
+	assert.Equal(t, *at(2), *timings[0].FirstReviewAt, "the author's own review is not counted")
+	assert.Equal(t, *at(5), *timings[0].ApprovedAt)
+	assert.Equal(t, *at(10), *timings[0].MergedAt)
+	assert.Equal(t, *at(3), *timings[1].ApprovedAt)
+
+	load, err := prs.GetReviewLoad(1, window)
+	assert.NoError(t, err)
+	assert.Len(t, load, 3)
+	assert.Equal(t, types.ReviewerLoad{Reviewer: "ada", PullRequests: 1, Reviews: 2, Approvals: 1, ChangesRequested: 1, Comments: 1}, load[0])
+	assert.Equal(t, "grace", load[1].Reviewer)
+	assert.Equal(t, 1, load[1].Reviews)
+	teardownTestDB()
+}
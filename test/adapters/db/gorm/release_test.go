@@ -0,0 +1,89 @@
+package gorm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleases(t *testing.T) {
+	db := setupTestDB()
+	releases := gorm.NewReleaseRepo(db)
+	day := func(d int) *time.Time {
+		at := time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+		return &at
+	}
+
+	err := releases.SaveReleases(1, []models.Release{
+		{GitHubID: 1, RepoID: 1, TagName: "v1.0", PublishedAt: day(1)},
+		{GitHubID: 2, RepoID: 1, TagName: "v1.1-rc1", Prerelease: true, PublishedAt: day(2)},
+		{GitHubID: 3, RepoID: 1, TagName: "v1.1", PublishedAt: day(3)},
+		{GitHubID: 4, RepoID: 1, TagName: "v1.2", Draft: true},
+	})
+	assert.NoError(t, err)
+	// releases point at the commits of their tags once those are stored
+	assert.NoError(t, releases.SaveTags(1, []models.Tag{{RepoID: 1, Name: "v1.0", SHA: "a"}, {RepoID: 1, Name: "v1.1", SHA: "c"}}))
+	first, err := releases.FindByTag(1, "v1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", first.TargetSHA)
+
+	latest, err := releases.FindLatest(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.1", latest.TagName)
+	previous, err := releases.FindPrevious(latest)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0", previous.TagName)
+	rc, _ := releases.FindByTag(1, "v1.1-rc1")
+	previous, _ = releases.FindPrevious(rc)
+	assert.Equal(t, "v1.0", previous.TagName)
+	_, err = releases.FindPrevious(first)
+	assert.Error(t, err)
+
+	listed, err := releases.FindReleases(1, 1, 10)
+	assert.NoError(t, err)
+	var tags []string
+	for _, r := range listed {
+		tags = append(tags, r.TagName)
+	}
+	assert.Equal(t, []string{"v1.1", "v1.1-rc1", "v1.0", "v1.2"}, tags)
+	page, err := releases.FindReleases(1, 2, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 2) {
+		assert.Equal(t, "v1.0", page[0].TagName)
+	}
+
+	// a moved tag is picked up on the next sync
+	assert.NoError(t, releases.SaveTags(1, []models.Tag{{RepoID: 1, Name: "v1.0", SHA: "b"}}))
+	first, _ = releases.FindByTag(1, "v1.0")
+	assert.Equal(t, "b", first.TargetSHA)
+	latest, _ = releases.FindByTag(1, "v1.1")
+	assert.Empty(t, latest.TargetSHA)
+	teardownTestDB()
+}
+
+func TestGetCommitAuthors(t *testing.T) {
+	db := setupTestDB()
+	commits := gorm.NewCommitRepo(db)
+	assert.NoError(t, commits.UpsertCommits([]models.Commit{
+		{Hash: "r1", RepoID: 1, Author: "tobi", AuthorEmail: "tobi@example.com", Message: "pair\n\nCo-authored-by: ada <ada@example.com>"},
+		{Hash: "r2", RepoID: 1, Author: "tobi", AuthorEmail: "tobi@example.com", Message: "solo"},
+		{Hash: "r3", RepoID: 1, Author: "grace", AuthorEmail: "grace@example.com", Message: "before the range"},
+	}))
+	stored, err := commits.FindByHashes(1, []string{"r1", "r2", "missing"})
+	assert.NoError(t, err)
+	assert.Len(t, stored, 2)
+
+	ids := []uint{stored[0].ID, stored[1].ID}
+	authors, err := commits.GetCommitAuthors(ids)
+	assert.NoError(t, err)
+	if assert.Len(t, authors, 2) {
+		assert.Equal(t, "tobi", authors[0].Author)
+		assert.Equal(t, 2, authors[0].CommitCount)
+		assert.Equal(t, "ada", authors[1].Author)
+		assert.Equal(t, 1, authors[1].CommitCount)
+	}
+	teardownTestDB()
+}
@@ -0,0 +1,67 @@
+package gorm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepositoryLifecycle(t *testing.T) {
+	db := setupTestDB()
+	repos := gorm.NewRepository(db)
+	commits := gorm.NewCommitRepo(db)
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	assert.NoError(t, repos.Create(&models.Repository{ID: 1, FullName: "org/repo"}))
+	assert.NoError(t, repos.Create(&models.Repository{ID: 2, FullName: "org/other"}))
+
+	page := []models.Commit{{Hash: "c2", RepoID: 1, CommitterDate: day(2)}, {Hash: "c1", RepoID: 1, CommitterDate: day(1)}}
+	assert.NoError(t, commits.UpsertPage(1, "", page, "c1"))
+	assert.NoError(t, commits.AdvanceHead(1, "", page[0]))
+	assert.NoError(t, commits.AdvanceHead(1, "release/1.0", page[0]))
+	assert.NoError(t, commits.UpsertPage(2, "", []models.Commit{{Hash: "o1", RepoID: 2, CommitterDate: day(1)}}, "o1"))
+	assert.NoError(t, db.Create(&models.Issue{RepoID: 1, Number: 1}).Error)
+
+	assert.NoError(t, repos.UpdateStatus(1, models.RepositoryPaused))
+	assert.NoError(t, repos.ResetCursors(1))
+	stored, _ := repos.FindByName("org/repo")
+	assert.Equal(t, models.RepositoryPaused, stored.Status)
+	assert.Empty(t, stored.HeadSHA)
+	assert.Nil(t, stored.HeadDate)
+	assert.Empty(t, stored.LastCommitSHA)
+	assert.Nil(t, stored.BackfillDate)
+	branches, _ := repos.FindBranches(1)
+	if assert.Len(t, branches, 1) {
+		assert.Empty(t, branches[0].HeadSHA)
+	}
+	// the commits stay until the repository is deleted
+	shared, err := commits.FindByHash("c2")
+	assert.NoError(t, err)
+	// a link of another repository's branch to one of its commits, as forks used to get
+	assert.NoError(t, db.Create(&models.CommitBranch{CommitID: shared.ID, RepoID: 2, Branch: "feature"}).Error)
+	assert.NoError(t, commits.UpsertPage(2, "feature", []models.Commit{{Hash: "o2", RepoID: 2, CommitterDate: day(2)}}, "o2"))
+
+	assert.NoError(t, repos.Delete(1))
+	assert.Error(t, repos.Delete(1))
+	_, err = repos.FindByName("org/repo")
+	assert.Error(t, err)
+	_, err = commits.FindByHash("c2")
+	assert.Error(t, err)
+	var count int64
+	db.Model(&models.Issue{}).Count(&count)
+	assert.Zero(t, count)
+	branches, _ = repos.FindBranches(1)
+	assert.Empty(t, branches)
+	// other repositories are left alone, apart from their links to its commits
+	_, err = commits.FindByHash("o1")
+	assert.NoError(t, err)
+	var links []models.CommitBranch
+	db.Find(&links)
+	if assert.Len(t, links, 1) {
+		assert.Equal(t, uint(2), links[0].RepoID)
+		assert.NotEqual(t, shared.ID, links[0].CommitID)
+	}
+	teardownTestDB()
+}
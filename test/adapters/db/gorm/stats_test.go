@@ -0,0 +1,70 @@
+package gorm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepositorySnapshots(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&models.Repository{ID: 1, FullName: "org/repo", StarsCount: 1})
+	repos := gorm.NewRepository(db)
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	meta := &models.Repository{StarsCount: 10, SubscribersCount: 3, Topics: []string{"go"}, DefaultBranch: "main"}
+	assert.NoError(t, repos.RecordSnapshot(1, meta, day(1)))
+	meta.StarsCount, meta.Archived = 12, true
+	assert.NoError(t, repos.RecordSnapshot(1, meta, day(2)))
+
+	stored, err := repos.FindByName("org/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, 12, stored.StarsCount)
+	assert.True(t, stored.Archived)
+	assert.Equal(t, []string{"go"}, stored.Topics)
+
+	snapshots, err := repos.FindSnapshots(1, types.DateWindow{Since: day(1), Until: day(3)})
+	assert.NoError(t, err)
+	if assert.Len(t, snapshots, 2) {
+		assert.Equal(t, 10, snapshots[0].Stars)
+		assert.Equal(t, 3, snapshots[0].Watchers)
+		assert.Equal(t, 12, snapshots[1].Stars)
+	}
+	teardownTestDB()
+}
+
+func TestStats(t *testing.T) {
+	db := setupTestDB()
+	stats := gorm.NewStatsRepo(db)
+	week := func(w int) time.Time { return time.Date(2024, 1, 7*w, 0, 0, 0, 0, time.UTC) }
+	window := types.DateWindow{Since: week(1), Until: week(3)}
+
+	assert.NoError(t, stats.SaveContributorStats(1, []models.ContributorWeek{
+		{RepoID: 1, Login: "tobi", Week: week(1), Commits: 2, Additions: 10},
+		{RepoID: 1, Login: "tobi", Week: week(2), Commits: 1, Deletions: 4},
+		{RepoID: 1, Login: "ada", Week: week(2), Commits: 5},
+		{RepoID: 1, Login: "ada", Week: week(3), Commits: 9},
+	}))
+	activity, err := stats.FindContributorActivity(1, window)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.ContributorActivity{
+		{Login: "ada", Commits: 5, ActiveWeeks: 1},
+		{Login: "tobi", Commits: 3, Additions: 10, Deletions: 4, ActiveWeeks: 2},
+	}, activity)
+
+	assert.NoError(t, stats.SaveCommitActivity(1, []models.CommitActivityWeek{{RepoID: 1, Week: week(1), Total: 1}, {RepoID: 1, Week: week(2), Total: 2}}))
+	// a later sync updates the weeks it returns and keeps the older ones
+	assert.NoError(t, stats.SaveCommitActivity(1, []models.CommitActivityWeek{{RepoID: 1, Week: week(2), Total: 6, Days: []int{0, 6, 0, 0, 0, 0, 0}}}))
+	weeks, err := stats.FindCommitActivity(1, window)
+	assert.NoError(t, err)
+	if assert.Len(t, weeks, 2) {
+		assert.Equal(t, 1, weeks[0].Total)
+		assert.Equal(t, 6, weeks[1].Total)
+		assert.Equal(t, []int{0, 6, 0, 0, 0, 0, 0}, weeks[1].Days)
+	}
+	teardownTestDB()
+}
@@ -0,0 +1,55 @@
+package gorm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowRuns(t *testing.T) {
+	db := setupTestDB()
+	commits := gorm.NewCommitRepo(db)
+	workflows := gorm.NewWorkflowRepo(db)
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	err := workflows.UpsertRuns([]models.WorkflowRun{
+		{GitHubID: 1, RepoID: 1, WorkflowName: "ci", HeadSHA: "w1", Status: "in_progress", CreatedAt: day(1)},
+		{GitHubID: 2, RepoID: 1, WorkflowName: "lint", HeadSHA: "w1", Status: models.WorkflowCompleted, Conclusion: models.WorkflowSuccess, CreatedAt: day(2)},
+		{GitHubID: 3, RepoID: 1, WorkflowName: "ci", HeadSHA: "w2", Status: models.WorkflowCompleted, Conclusion: "failure", CreatedAt: day(5)},
+	})
+	assert.NoError(t, err)
+	// a run that completed since is refreshed
+	err = workflows.UpsertRuns([]models.WorkflowRun{
+		{GitHubID: 1, RepoID: 1, WorkflowName: "ci", HeadSHA: "w1", Status: models.WorkflowCompleted, Conclusion: models.WorkflowSuccess, RunAttempt: 2, CreatedAt: day(1)},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, workflows.SaveJobs(1, []models.WorkflowJob{
+		{GitHubID: 11, RunID: 1, RepoID: 1, Name: "test", RunAttempt: 2, Conclusion: models.WorkflowSuccess},
+		{GitHubID: 10, RunID: 1, RepoID: 1, Name: "test", RunAttempt: 1, Conclusion: "failure"},
+	}))
+
+	// the head commit arrives after its runs
+	assert.NoError(t, commits.UpsertCommits([]models.Commit{{Hash: "w1", RepoID: 1}}))
+	runs, err := workflows.FindRunsByCommit(1, "w1")
+	assert.NoError(t, err)
+	if assert.Len(t, runs, 2) {
+		assert.Equal(t, models.WorkflowCompleted, runs[0].Status)
+		assert.Equal(t, 2, runs[0].RunAttempt)
+		assert.NotZero(t, runs[0].CommitID)
+		if assert.Len(t, runs[0].Jobs, 2) {
+			assert.Equal(t, 1, runs[0].Jobs[0].RunAttempt)
+		}
+		assert.Empty(t, runs[1].Jobs)
+	}
+
+	runs, err = workflows.FindRuns(1, types.DateWindow{Since: day(1), Until: day(5)}, "ci")
+	assert.NoError(t, err)
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, int64(1), runs[0].GitHubID)
+	}
+	teardownTestDB()
+}
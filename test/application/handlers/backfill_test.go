@@ -0,0 +1,105 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/api"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/adapters/db/gorm"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/application/handlers"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	gm "gorm.io/gorm"
+)
+
+var dbFilePath = "test.db"
+
+func setupHandler(t *testing.T, server *httptest.Server) *handlers.AppHandler {
+	db, err := gm.Open(sqlite.Open(dbFilePath), &gm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, gorm.Migrate(db))
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		os.Remove(dbFilePath)
+	})
+	logger, _ := zap.NewDevelopment()
+	githubApi := api.NewGitHubAPI(api.Options{BaseURL: server.URL}, logger)
+	h := handlers.NewAppHandler(gorm.NewRepository(db), gorm.NewCommitRepo(db), gorm.NewIdentityRepo(db), gorm.NewPullRequestRepo(db),
+		gorm.NewIssueRepo(db), gorm.NewReleaseRepo(db), gorm.NewStatsRepo(db), gorm.NewWorkflowRepo(db), gorm.NewEnrollmentRepo(db), githubApi, logger)
+	h.Backfill = handlers.BackfillOptions{Workers: 2, Window: 24 * time.Hour}
+	return h
+}
+
+// commitsServer answers the commit list with the commits of days, one a day at
+// noon, that fall in the requested since..until range, newest first. Ranges
+// starting on a failing day are answered with 404.
+func commitsServer(days []int, failing ...int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since, _ := time.Parse(time.RFC3339, r.URL.Query().Get("since"))
+		until, _ := time.Parse(time.RFC3339, r.URL.Query().Get("until"))
+		for _, day := range failing {
+			if since.Day() == day {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		}
+		body := "["
+		for i := len(days) - 1; i >= 0; i-- {
+			date := time.Date(2024, 1, days[i], 12, 0, 0, 0, time.UTC)
+			if date.Before(since) || !date.Before(until) {
+				continue
+			}
+			if body != "[" {
+				body += ","
+			}
+			body += fmt.Sprintf(`{"sha": "c%d", "commit": {"author": {"date": %q}, "committer": {"date": %q}}}`,
+				days[i], date.Format(time.RFC3339), date.Format(time.RFC3339))
+		}
+		w.Write([]byte(body + "]"))
+	}))
+}
+
+func TestBackfillLinksDefaultBranch(t *testing.T) {
+	server := commitsServer([]int{1, 2, 3, 4})
+	defer server.Close()
+	h := setupHandler(t, server)
+	repo := &models.Repository{FullName: "org/repo", DefaultBranch: "main"}
+	assert.NoError(t, h.RepositoryRepo.Create(repo))
+
+	windows, err := utils.SplitDateRange("2024-01-01", "2024-01-05", 24*time.Hour)
+	assert.NoError(t, err)
+	assert.NoError(t, h.BackfillRepository(repo, windows))
+
+	commits, err := h.CommitRepo.FindByRepoId(repo.ID, types.CommitFilter{Branch: "main"}, 1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, commits, 4)
+	stored, _ := h.RepositoryRepo.FindByName("org/repo")
+	assert.Equal(t, "c4", stored.HeadSHA)
+	assert.Equal(t, "c1", stored.LastCommitSHA)
+}
+
+func TestBackfillKeepsFinishedWindows(t *testing.T) {
+	// the windows of days 2 and 4 fail: only the newest one is covered without a gap
+	server := commitsServer([]int{1, 2, 3, 4, 5}, 2, 4)
+	defer server.Close()
+	h := setupHandler(t, server)
+	repo := &models.Repository{FullName: "org/repo", DefaultBranch: "main"}
+	assert.NoError(t, h.RepositoryRepo.Create(repo))
+
+	windows, err := utils.SplitDateRange("2024-01-01", "2024-01-06", 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Error(t, h.BackfillRepository(repo, windows))
+	stored, _ := h.RepositoryRepo.FindByName("org/repo")
+	assert.Equal(t, "c5", stored.HeadSHA)
+	assert.Equal(t, "c5", stored.LastCommitSHA)
+}
@@ -0,0 +1,40 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrichmentStopsWhenDisabled(t *testing.T) {
+	var disable func()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			disable()
+		}
+		sha := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		fmt.Fprintf(w, `{"sha": %q, "stats": {"additions": 1, "deletions": 0, "total": 1}, "files": []}`, sha)
+	}))
+	defer server.Close()
+	app := setupHandler(t, server)
+	repo := &models.Repository{FullName: "org/repo", EnrichCommits: true}
+	assert.NoError(t, app.RepositoryRepo.Create(repo))
+	disable = func() { assert.NoError(t, app.RepositoryRepo.UpdateEnrichCommits(repo.ID, false)) }
+
+	commits := make([]models.Commit, 60)
+	for i := range commits {
+		commits[i] = models.Commit{Hash: fmt.Sprintf("c%d", i), RepoID: repo.ID}
+	}
+	assert.NoError(t, app.CommitRepo.UpsertCommits(commits))
+
+	// turned off during the first batch: the batch is finished, the next one never starts
+	assert.NoError(t, app.EnrichCommits(repo))
+	assert.Equal(t, int32(50), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,53 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncPullRequestsOverlapsWatermark(t *testing.T) {
+	watermark := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	updated := map[int]time.Time{
+		1: watermark.Add(time.Hour),
+		// passed over by the last sync although updated just before its watermark
+		2: watermark.Add(-30 * time.Minute),
+		3: watermark.Add(-2 * time.Hour),
+	}
+	pr := func(number int) string {
+		return fmt.Sprintf(`{"number": %d, "state": "open", "updated_at": %q}`, number, updated[number].Format(time.RFC3339))
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/repo/pulls":
+			fmt.Fprintf(w, "[%s, %s, %s]", pr(1), pr(2), pr(3))
+		case "/repos/org/repo/pulls/1", "/repos/org/repo/pulls/2", "/repos/org/repo/pulls/3":
+			var number int
+			fmt.Sscanf(r.URL.Path, "/repos/org/repo/pulls/%d", &number)
+			w.Write([]byte(pr(number)))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+	app := setupHandler(t, server)
+	repo := &models.Repository{FullName: "org/repo", PullsSyncedAt: &watermark}
+	assert.NoError(t, app.RepositoryRepo.Create(repo))
+
+	assert.NoError(t, app.SyncPullRequests(repo))
+	stored, err := app.PullRequestRepo.FindByRepoId(repo.ID, types.PullRequestFilter{}, 1, 10)
+	assert.NoError(t, err)
+	var numbers []int
+	for _, p := range stored {
+		numbers = append(numbers, p.Number)
+	}
+	assert.ElementsMatch(t, []int{1, 2}, numbers)
+	synced, _ := app.RepositoryRepo.FindByName("org/repo")
+	assert.True(t, updated[1].Equal(*synced.PullsSyncedAt))
+}
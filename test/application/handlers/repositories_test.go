@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/application/handlers"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// deleteRoute serves DeleteRepository, returning the status code of deleting a repository
+func deleteRoute(app *handlers.AppHandler) func(fullName string) int {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.DELETE("/repos/:owner/:repo", app.DeleteRepository)
+	return func(fullName string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/repos/"+fullName, nil))
+		return w.Code
+	}
+}
+
+func TestDeleteEnrolledRepository(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	app := setupHandler(t, server)
+	enrollment := &models.Enrollment{Owner: "org"}
+	assert.NoError(t, app.EnrollmentRepo.Save(enrollment))
+	enrolled := &models.Repository{FullName: "org/enrolled", EnrollmentID: enrollment.ID, Status: models.RepositoryActive}
+	dropped := &models.Repository{FullName: "org/dropped", EnrollmentID: enrollment.ID, Status: models.RepositoryInactive}
+	assert.NoError(t, app.RepositoryRepo.Create(enrolled))
+	assert.NoError(t, app.RepositoryRepo.Create(dropped))
+
+	del := deleteRoute(app)
+	// the next sync of the enrollment would add it again
+	assert.Equal(t, http.StatusConflict, del("org/enrolled"))
+	// no longer matching, so it stays deleted
+	assert.Equal(t, http.StatusOK, del("org/dropped"))
+
+	assert.NoError(t, app.EnrollmentRepo.Delete("org"))
+	assert.Equal(t, http.StatusOK, del("org/enrolled"))
+	_, err := app.RepositoryRepo.FindByName("org/enrolled")
+	assert.Error(t, err)
+}
+
+func TestDeleteRepositoryWhileSyncing(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	app := setupHandler(t, server)
+	repo := &models.Repository{FullName: "org/repo", DefaultBranch: "main", Status: models.RepositoryActive}
+	assert.NoError(t, app.RepositoryRepo.Create(repo))
+	del := deleteRoute(app)
+
+	done := make(chan struct{})
+	go func() {
+		app.HandleAddCommitEvent(events.AddCommitEvent{Repo: repo})
+		close(done)
+	}()
+	<-started
+	// the sync would go on storing rows for the deleted repository
+	assert.Equal(t, http.StatusConflict, del("org/repo"))
+	close(release)
+	<-done
+
+	assert.Equal(t, http.StatusOK, del("org/repo"))
+	_, err := app.RepositoryRepo.FindByName("org/repo")
+	assert.Error(t, err)
+}
+
+func TestForkImportStartsAtCreationWhenParentMonitored(t *testing.T) {
+	var mu sync.Mutex
+	var since []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/someone/repo":
+			w.Write([]byte(`{"full_name": "someone/repo", "fork": true, "parent": {"full_name": "org/repo"}}`))
+		case "/repos/someone/repo/commits":
+			mu.Lock()
+			since = append(since, r.URL.Query().Get("since"))
+			mu.Unlock()
+			w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	app := setupHandler(t, server)
+	app.StartMonitoring()
+	fork := &models.Repository{FullName: "someone/repo", DefaultBranch: "main", Fork: true, CreatedAt: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	assert.NoError(t, app.RepositoryRepo.Create(fork))
+
+	// the parent is not monitored: the whole history is the fork's
+	app.HandleAddCommitEvent(events.AddCommitEvent{Repo: fork})
+	assert.NoError(t, app.RepositoryRepo.Create(&models.Repository{FullName: "org/repo", Status: models.RepositoryActive}))
+	app.HandleAddCommitEvent(events.AddCommitEvent{Repo: fork})
+
+	if assert.Len(t, since, 2) {
+		assert.Empty(t, since[0])
+		assert.True(t, strings.HasPrefix(since[1], "2024-03-01"), since[1])
+	}
+}
@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStuckWorkflowRunDoesNotHoldWatermark(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	completed := now.Add(-time.Hour)
+	runs := fmt.Sprintf(`{"id": 1, "status": "completed", "created_at": %q}, {"id": 2, "status": "queued", "created_at": %q}`,
+		completed.Format(time.RFC3339), now.Add(-10*24*time.Hour).Format(time.RFC3339))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/repo/actions/runs":
+			fmt.Fprintf(w, `{"total_count": 3, "workflow_runs": [%s]}`, runs)
+		default:
+			w.Write([]byte(`{"jobs": []}`))
+		}
+	}))
+	defer server.Close()
+	app := setupHandler(t, server)
+	repo := &models.Repository{FullName: "org/repo"}
+	assert.NoError(t, app.RepositoryRepo.Create(repo))
+
+	// queued for ten days: it no longer holds the watermark back
+	assert.NoError(t, app.SyncWorkflowRuns(repo))
+	stored, _ := app.RepositoryRepo.FindByName("org/repo")
+	if assert.NotNil(t, stored.RunsSyncedAt) {
+		assert.True(t, completed.Equal(*stored.RunsSyncedAt), stored.RunsSyncedAt)
+	}
+
+	// in progress for two hours: the next sync fetches from it again
+	pending := now.Add(-2 * time.Hour)
+	runs += fmt.Sprintf(`, {"id": 3, "status": "in_progress", "created_at": %q}`, pending.Format(time.RFC3339))
+	assert.NoError(t, app.SyncWorkflowRuns(stored))
+	stored, _ = app.RepositoryRepo.FindByName("org/repo")
+	if assert.NotNil(t, stored.RunsSyncedAt) {
+		assert.True(t, pending.Equal(*stored.RunsSyncedAt), stored.RunsSyncedAt)
+	}
+}
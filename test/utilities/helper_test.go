@@ -2,7 +2,9 @@ package utilities_test
 
 import (
 	"testing"
+	"time"
 
+	"github.com/oluwatobi1/gh-api-data-fetch/internal/core/domain/models"
 	"github.com/oluwatobi1/gh-api-data-fetch/internal/utils"
 	"github.com/stretchr/testify/assert"
 )
@@ -14,3 +16,119 @@ func TestParseLinkHeader(t *testing.T) {
 	assert.Equal(t, "https://api.github.com/repositories/1300192/issues?page=4", links["next"])
 
 }
+
+func TestSplitDateRange(t *testing.T) {
+	windows, err := utils.SplitDateRange("2024-07-01", "2024-07-10", 96*time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, windows, 3)
+	assert.Equal(t, "2024-07-01", windows[0].Since.Format("2006-01-02"))
+	assert.Equal(t, windows[0].Until, windows[1].Since)
+	assert.Equal(t, "2024-07-09", windows[2].Since.Format("2006-01-02"))
+	assert.Equal(t, "2024-07-10", windows[2].Until.Format("2006-01-02"))
+
+	_, err = utils.SplitDateRange("2024-08-02", "2024-07-02", 24*time.Hour)
+	assert.Error(t, err)
+}
+
+func TestParseTrailers(t *testing.T) {
+	message := "Add retries\n\nLonger description.\n\n" +
+		"Co-authored-by: Ada Lovelace <Ada@Example.com>\n" +
+		"co-authored-by: dependabot[bot] <49699333+dependabot[bot]@users.noreply.github.com>\n" +
+		"Co-authored-by: Ada L. <ada@example.com>\n" +
+		"Signed-off-by: Tobi <tobi@example.com>\n" +
+		"Acked-by: Grace <grace@example.com>\n" +
+		"Reviewed-by: no email here"
+	trailers := utils.ParseTrailers(message)
+	assert.Equal(t, []utils.Trailer{
+		{Role: "co_author", Name: "Ada Lovelace", Email: "ada@example.com"},
+		{Role: "co_author", Name: "dependabot[bot]", Email: "49699333+dependabot[bot]@users.noreply.github.com"},
+		{Role: "signer", Name: "Tobi", Email: "tobi@example.com"},
+	}, trailers)
+}
+
+func TestParseMailmap(t *testing.T) {
+	content := "# team\n" +
+		"Tobi <tobi@example.com>\n" +
+		"<ada@example.com> <ada@old.com>\n" +
+		"Ada Lovelace <ada@example.com> ada <ADA@laptop>\n" +
+		"not an entry\n"
+	rules := utils.ParseMailmap(content, "mailmap")
+	assert.Len(t, rules, 3)
+	assert.Equal(t, "tobi@example.com", rules[0].MatchEmail)
+	assert.Equal(t, "", rules[0].CanonicalEmail)
+	assert.Equal(t, "ada@old.com", rules[1].MatchEmail)
+	assert.Equal(t, "ada@laptop", rules[2].MatchEmail)
+	assert.Equal(t, "ada", rules[2].MatchName)
+
+	name, email := utils.ApplyAliasRules(rules, "ada", "ada@laptop")
+	assert.Equal(t, "Ada Lovelace", name)
+	assert.Equal(t, "ada@example.com", email)
+	name, email = utils.ApplyAliasRules(rules, "someone", "ada@laptop")
+	assert.Equal(t, "someone", name)
+	assert.Equal(t, "ada@laptop", email)
+	name, _ = utils.ApplyAliasRules(rules, "tobi", "tobi@example.com")
+	assert.Equal(t, "Tobi", name)
+}
+
+func TestPercentiles(t *testing.T) {
+	percentiles, err := utils.ParsePercentiles("50, p90,99.9")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{50, 90, 99.9}, percentiles)
+	_, err = utils.ParsePercentiles("50,101")
+	assert.Error(t, err)
+
+	stats := utils.Percentiles([]float64{4, 1, 3, 2, 10}, []float64{50, 90})
+	assert.Equal(t, 3.0, stats["p50"])
+	assert.InDelta(t, 7.6, stats["p90"], 1e-9)
+	assert.Nil(t, utils.Percentiles(nil, []float64{50}))
+}
+
+func TestParseDateRange(t *testing.T) {
+	window, err := utils.ParseDateRange("2024-07-01", "2024-07-10", 30)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-07-01", window.Since.Format("2006-01-02"))
+	// the to date is included
+	assert.Equal(t, "2024-07-11", window.Until.Format("2006-01-02"))
+
+	window, err = utils.ParseDateRange("", "2024-07-10", 30)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-06-10", window.Since.Format("2006-01-02"))
+
+	_, err = utils.ParseDateRange("2024-07-11", "2024-07-10", 30)
+	assert.Error(t, err)
+}
+
+func TestParseIssueReferences(t *testing.T) {
+	refs := utils.ParseIssueReferences("Fixes #12, see #7 and GH-7\n\nCloses: #3, refs org/repo#9 and https://example.com/#40")
+	assert.Equal(t, []utils.IssueReference{
+		{Number: 12, Closes: true},
+		{Number: 7},
+		{Number: 3, Closes: true},
+	}, refs)
+	// a later closing mention wins
+	assert.Equal(t, []utils.IssueReference{{Number: 5, Closes: true}}, utils.ParseIssueReferences("#5 resolved\n\nresolves #5"))
+	assert.Nil(t, utils.ParseIssueReferences("no issue here, abc#1"))
+}
+
+func TestParseEnrollmentSpecs(t *testing.T) {
+	requests, err := utils.ParseEnrollmentSpecs(" my-org?language=Go&include_forks=true&name_regex=^api- ; other-user ;")
+	assert.NoError(t, err)
+	if assert.Len(t, requests, 2) {
+		assert.Equal(t, "my-org", requests[0].Owner)
+		assert.Equal(t, "Go", requests[0].Language)
+		assert.True(t, requests[0].IncludeForks)
+		assert.Equal(t, "^api-", requests[0].NameRegex)
+		assert.Equal(t, "other-user", requests[1].Owner)
+	}
+
+	matches, err := requests[0].ToEnrollment().Matcher()
+	assert.NoError(t, err)
+	assert.True(t, matches(&models.Repository{Name: "api-core", Language: "go", Fork: true}))
+	assert.False(t, matches(&models.Repository{Name: "web", Language: "Go"}))
+	assert.False(t, matches(&models.Repository{Name: "api-old", Language: "Go", Archived: true}))
+
+	for _, spec := range []string{"org?stars=5", "org?visibility=secret", "org?name_regex=(", "org/repo", "org?include_forks=maybe"} {
+		_, err := utils.ParseEnrollmentSpecs(spec)
+		assert.Error(t, err, spec)
+	}
+}